@@ -7,8 +7,10 @@ toolchain go1.23.4
 require (
 	github.com/clambin/go-common/set v0.4.3
 	github.com/clambin/go-common/testutils v0.5.0
+	github.com/coder/websocket v1.8.12
 	github.com/oapi-codegen/runtime v1.1.1
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/time v0.8.0
 )
 
 require (
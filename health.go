@@ -0,0 +1,41 @@
+// Package mediaclients contains helpers that apply to all media clients in this module.
+package mediaclients
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A HealthChecker checks if a service is available. Each client has a cheap endpoint that can be used for this, e.g.:
+//
+//	func(ctx context.Context) error { _, err := plexClient.GetIdentity(ctx); return err }
+type HealthChecker func(ctx context.Context) error
+
+// Result contains the outcome of one HealthChecker
+type Result struct {
+	// Err is nil if the service is healthy
+	Err error
+	// Duration is the time it took to perform the check
+	Duration time.Duration
+}
+
+// HealthCheck runs all checks concurrently and returns their results, in the same order as the checks.
+// Each check is cancelled if it doesn't complete within the specified timeout.
+func HealthCheck(ctx context.Context, timeout time.Duration, checks ...HealthChecker) []Result {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i, check := range checks {
+		go func(i int, check HealthChecker) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			results[i].Err = check(ctx)
+			results[i].Duration = time.Since(start)
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,27 @@
+package mediaclients_test
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/mediaclients"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestHealthCheck(t *testing.T) {
+	healthy := func(_ context.Context) error { return nil }
+	failing := func(_ context.Context) error { return errors.New("connection refused") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	results := mediaclients.HealthCheck(context.Background(), 100*time.Millisecond, healthy, failing, hanging)
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.EqualError(t, results[1].Err, "connection refused")
+	assert.ErrorIs(t, results[2].Err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, results[2].Duration, 100*time.Millisecond)
+}
@@ -0,0 +1,67 @@
+// Package backoff calculates the delays between attempts of an operation that is retried.
+package backoff
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay before the next attempt. attempt is the number of attempts that have failed so far, minus one:
+// Next(0) is the delay after the first failure.
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+var (
+	_ Backoff = Exponential{}
+	_ Backoff = Constant{}
+)
+
+// Exponential is a Backoff whose delay starts at Initial and is multiplied by Multiplier (default: 2) after each attempt,
+// up to Max. If Max is zero, the delay is not capped.
+//
+// Jitter (between 0 and 1) randomly reduces each delay by up to that fraction, so that clients that fail at the same time
+// don't all retry at the same time.
+type Exponential struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// Next returns the delay before the next attempt.
+func (e Exponential) Next(attempt int) time.Duration {
+	multiplier := e.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(e.Initial) * math.Pow(multiplier, float64(max(attempt, 0)))
+	if e.Max > 0 && delay > float64(e.Max) {
+		delay = float64(e.Max)
+	}
+	// guard against overflow for large attempts without a cap
+	if delay >= math.MaxInt64 {
+		return jitter(math.MaxInt64, e.Jitter)
+	}
+	return jitter(time.Duration(delay), e.Jitter)
+}
+
+// Constant is a Backoff that always returns Delay, randomly reduced by up to Jitter (between 0 and 1).
+type Constant struct {
+	Delay  time.Duration
+	Jitter float64
+}
+
+// Next returns the delay before the next attempt.
+func (c Constant) Next(_ int) time.Duration {
+	return jitter(c.Delay, c.Jitter)
+}
+
+func jitter(delay time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || delay <= 0 {
+		return delay
+	}
+	fraction = min(fraction, 1)
+	return delay - time.Duration(rand.Float64()*fraction*float64(delay))
+}
@@ -0,0 +1,72 @@
+package backoff_test
+
+import (
+	"github.com/clambin/mediaclients/internal/backoff"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff backoff.Exponential
+		want    []time.Duration
+	}{
+		{
+			name:    "default multiplier",
+			backoff: backoff.Exponential{Initial: time.Second},
+			want:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			name:    "capped",
+			backoff: backoff.Exponential{Initial: time.Second, Max: 5 * time.Second},
+			want:    []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			name:    "multiplier",
+			backoff: backoff.Exponential{Initial: 100 * time.Millisecond, Multiplier: 1.5},
+			want:    []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 225 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for attempt, want := range tt.want {
+				assert.Equal(t, want, tt.backoff.Next(attempt), attempt)
+			}
+		})
+	}
+}
+
+func TestExponential_Overflow(t *testing.T) {
+	b := backoff.Exponential{Initial: time.Second}
+	assert.Positive(t, b.Next(1000))
+
+	b.Max = time.Hour
+	assert.Equal(t, time.Hour, b.Next(1000))
+}
+
+func TestExponential_Jitter(t *testing.T) {
+	b := backoff.Exponential{Initial: time.Second, Max: 4 * time.Second, Jitter: 0.5}
+	for range 100 {
+		for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+			delay := b.Next(attempt)
+			assert.LessOrEqual(t, delay, want)
+			assert.GreaterOrEqual(t, delay, want/2)
+		}
+	}
+}
+
+func TestConstant(t *testing.T) {
+	b := backoff.Constant{Delay: time.Second}
+	for attempt := range 5 {
+		assert.Equal(t, time.Second, b.Next(attempt))
+	}
+
+	b.Jitter = 0.25
+	for attempt := range 100 {
+		delay := b.Next(attempt)
+		assert.LessOrEqual(t, delay, time.Second)
+		assert.GreaterOrEqual(t, delay, 750*time.Millisecond)
+	}
+}
@@ -32,9 +32,23 @@ func (a *authenticator) RoundTrip(request *http.Request) (*http.Response, error)
 		return nil, err
 	}
 	request.Header.Add("X-Plex-Token", a.authToken)
+	a.setUserAgent(request)
 	return a.next.RoundTrip(request)
 }
 
+// setUserAgent identifies the client as "product/version" in the request's User-Agent header (unless the request already
+// has one), so the client can be recognized in the server's logs.
+func (a *authenticator) setUserAgent(request *http.Request) {
+	if a.product == "" || request.Header.Get("User-Agent") != "" {
+		return
+	}
+	userAgent := a.product
+	if a.version != "" {
+		userAgent += "/" + a.version
+	}
+	request.Header.Set("User-Agent", userAgent)
+}
+
 // SetAuthToken sets the AuthToken
 func (a *authenticator) SetAuthToken(s string) {
 	a.lock.Lock()
@@ -62,7 +76,10 @@ func (a *authenticator) authenticate(ctx context.Context) error {
 		return nil
 	}
 
-	req, _ := a.makeAuthRequest(ctx)
+	req, err := a.makeAuthRequest(ctx)
+	if err != nil {
+		return fmt.Errorf("plex auth: %w", err)
+	}
 	resp, err := a.httpClient.Do(req)
 	if err != nil {
 		return err
@@ -88,6 +105,7 @@ func (a *authenticator) makeAuthRequest(ctx context.Context) (*http.Request, err
 		req.Header.Add("X-Plex-Product", a.product)
 		req.Header.Add("X-Plex-Version", a.version)
 		req.Header.Add("X-Plex-Client-Identifier", a.product+"-v"+a.version)
+		a.setUserAgent(req)
 	}
 	return req, err
 }
@@ -67,6 +67,51 @@ func TestAuthenticator_Custom_RoundTripper(t *testing.T) {
 	assert.Contains(t, err.Error(), "plex auth: 403 Forbidden")
 }
 
+func TestClient_CheckAuth(t *testing.T) {
+	authServer := httptest.NewServer(http.HandlerFunc(testutil.AuthHandler))
+	defer authServer.Close()
+	server := httptest.NewServer(testutil.WithToken("some_token", &testutil.TestServer))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		password  string
+		authToken string
+		wantErr   assert.ErrorAssertionFunc
+	}{
+		{
+			name:     "valid credentials",
+			password: "somepassword",
+			wantErr:  assert.NoError,
+		},
+		{
+			name:     "invalid credentials",
+			password: "badpassword",
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrUnauthorized)
+			},
+		},
+		{
+			name:      "invalid token",
+			authToken: "bad_token",
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, ErrUnauthorized)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New("user@example.com", tt.password, "", "", server.URL, nil)
+			c.authenticator.authURL = authServer.URL
+			if tt.authToken != "" {
+				c.SetAuthToken(tt.authToken)
+			}
+			tt.wantErr(t, c.CheckAuth(context.Background()))
+		})
+	}
+}
+
 var _ http.RoundTripper = &dummyRoundTripper{}
 
 type dummyRoundTripper struct {
@@ -128,3 +173,32 @@ func TestClient_GetAuthToken(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthenticator_UserAgent(t *testing.T) {
+	var authUserAgent, userAgent string
+	authServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authUserAgent = r.Header.Get("User-Agent")
+		testutil.AuthHandler(w, r)
+	}))
+	defer authServer.Close()
+	server := httptest.NewServer(testutil.WithToken("some_token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		testutil.TestServer.ServeHTTP(w, r)
+	})))
+	defer server.Close()
+
+	c := New("user@example.com", "somepassword", "myapp", "1.2.3", server.URL, nil)
+	c.authenticator.authURL = authServer.URL
+
+	_, err := c.GetIdentity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "myapp/1.2.3", authUserAgent)
+	assert.Equal(t, "myapp/1.2.3", userAgent)
+
+	// without a product, the default User-Agent is used
+	c = New("user@example.com", "somepassword", "", "", server.URL, nil)
+	c.SetAuthToken("some_token")
+	_, err = c.GetIdentity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Go-http-client/1.1", userAgent)
+}
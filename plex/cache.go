@@ -0,0 +1,169 @@
+package plex
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores responses from the Plex Media Server. Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored for key, if it exists and has not expired.
+	Get(key string) ([]byte, bool)
+	// Set stores the value for key, for the specified duration.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// WithCache caches the responses of library requests (i.e. /library/...) for the specified duration.
+// Use ForceRefresh to bypass the cache for a request. NewMemoryCache provides a simple in-memory Cache.
+//
+// A Cache may be shared by several Clients: each Client only uses the entries it stored itself.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(c *Client) {
+		c.cache = cache
+		c.cacheTTL = ttl
+		c.cacheID = newCacheID()
+	}
+}
+
+// newCacheID returns a random ID, to separate the cache entries of different Clients. Clients may talk to different
+// servers, or use a different token (i.e. user) for the same server, so they can't use each other's responses.
+func newCacheID() string {
+	var id [8]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+type forceRefreshKey struct{}
+
+// ForceRefresh returns a context that makes the Client bypass its cache: the response is retrieved from the server
+// (and stored in the cache).
+func ForceRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceRefreshKey{}, true)
+}
+
+func cacheable(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "/library/")
+}
+
+// PrefixDeleter is implemented by a Cache that can delete entries. If the Client's Cache implements it,
+// invalidating the cache deletes the Client's entries, rather than leaving them to expire.
+type PrefixDeleter interface {
+	// DeletePrefix deletes all entries whose key starts with prefix.
+	DeletePrefix(prefix string)
+}
+
+// invalidateCache invalidates all cached responses. It changes the cache keys, so old entries are no longer used.
+// If the Cache supports it, the old entries are deleted. Otherwise, they are left to expire.
+func (c *Client) invalidateCache() {
+	c.cacheGeneration.Add(1)
+	if deleter, ok := c.cache.(PrefixDeleter); ok {
+		deleter.DeletePrefix(c.cacheKeyPrefix())
+	}
+}
+
+// cacheKeyPrefix returns the prefix of all of the Client's cache keys.
+func (c *Client) cacheKeyPrefix() string {
+	return c.cacheID + "|" + c.URL + "|"
+}
+
+func (c *Client) getCached(ctx context.Context, endpoint string, headers http.Header) ([]byte, error) {
+	key := c.cacheKeyPrefix() + strconv.FormatUint(c.cacheGeneration.Load(), 10) + "|" + endpoint
+	if start := headers.Get("X-Plex-Container-Start"); start != "" {
+		key += "|" + start + "/" + headers.Get("X-Plex-Container-Size")
+	}
+	if refresh, _ := ctx.Value(forceRefreshKey{}).(bool); !refresh {
+		if body, ok := c.cache.Get(key); ok {
+			return body, nil
+		}
+	}
+
+	resp, err := c.get(ctx, endpoint, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	r, err := c.responseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	c.cache.Set(key, body, c.cacheTTL)
+	return body, nil
+}
+
+var (
+	_ Cache         = &MemoryCache{}
+	_ PrefixDeleter = &MemoryCache{}
+)
+
+// MemoryCache is a simple in-memory Cache. Expired entries are removed when they're accessed, or when a new entry is added.
+type MemoryCache struct {
+	lock    sync.Mutex
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	value  []byte
+	expiry time.Time
+}
+
+// NewMemoryCache returns a new, empty MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryCacheEntry)}
+}
+
+// Get returns the value stored for key, if it exists and has not expired.
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiry) {
+		delete(m.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// Set stores the value for key, for the specified duration.
+func (m *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	now := time.Now()
+	for k, entry := range m.entries {
+		if now.After(entry.expiry) {
+			delete(m.entries, k)
+		}
+	}
+	m.entries[key] = memoryCacheEntry{value: value, expiry: now.Add(ttl)}
+}
+
+// DeletePrefix deletes all entries whose key starts with prefix.
+func (m *MemoryCache) DeletePrefix(prefix string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for k := range m.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(m.entries, k)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package plex_test
+
+import (
+	"context"
+	"github.com/clambin/mediaclients/plex"
+	"github.com/clambin/mediaclients/plex/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCache(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		testutil.TestServer.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithCache(plex.NewMemoryCache(), 100*time.Millisecond))
+	c.HTTPClient.Transport = http.DefaultTransport
+	ctx := context.Background()
+
+	// first call hits the server
+	movies, err := c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, []plex.Movie{{Guid: "1", Title: "foo"}}, movies)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// second call is served from the cache
+	movies, err = c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, []plex.Movie{{Guid: "1", Title: "foo"}}, movies)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// forced refresh bypasses the cache
+	_, err = c.GetMovies(plex.ForceRefresh(ctx), "1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// non-library calls aren't cached
+	_, err = c.GetSessions(ctx)
+	require.NoError(t, err)
+	_, err = c.GetSessions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), calls.Load())
+
+	// expired entries are retrieved from the server
+	time.Sleep(150 * time.Millisecond)
+	_, err = c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), calls.Load())
+
+	// errors aren't cached
+	_, err = c.GetMovies(ctx, "99")
+	require.Error(t, err)
+	_, err = c.GetMovies(ctx, "99")
+	require.Error(t, err)
+	assert.Equal(t, int32(7), calls.Load())
+}
+
+func TestWithCache_Invalidate(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/:/scrobble" {
+			return
+		}
+		calls.Add(1)
+		testutil.TestServer.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+
+	cache := keyRecordingCache{MemoryCache: plex.NewMemoryCache()}
+	cache.Set("other", []byte("foo"), time.Hour)
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithCache(&cache, time.Hour))
+	c.HTTPClient.Transport = http.DefaultTransport
+	ctx := context.Background()
+
+	_, err := c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	_, err = c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// marking an item as watched invalidates the cache
+	require.NoError(t, c.MarkWatched(ctx, "1"))
+	_, err = c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// the old entries were deleted, but other entries were kept
+	_, ok := cache.Get(cache.keys[1])
+	assert.False(t, ok)
+	_, ok = cache.Get("other")
+	assert.True(t, ok)
+
+	// a failed request doesn't
+	require.Error(t, c.RefreshMetadata(ctx, "1"))
+	_, err = c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+// keyRecordingCache records the keys that are stored in the cache.
+type keyRecordingCache struct {
+	*plex.MemoryCache
+	keys []string
+}
+
+func (c *keyRecordingCache) Set(key string, value []byte, ttl time.Duration) {
+	c.keys = append(c.keys, key)
+	c.MemoryCache.Set(key, value, ttl)
+}
+
+func TestWithCache_Shared(t *testing.T) {
+	newServer := func(title string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{ "MediaContainer": { "size": 1, "Metadata": [ { "title": "` + title + `" } ] } }`))
+		}))
+	}
+	s1 := newServer("foo")
+	defer s1.Close()
+	s2 := newServer("bar")
+	defer s2.Close()
+
+	cache := plex.NewMemoryCache()
+	c1 := plex.New("user@example.com", "somepassword", "", "", s1.URL, nil, plex.WithCache(cache, time.Hour))
+	c1.HTTPClient.Transport = http.DefaultTransport
+	c2 := plex.New("user@example.com", "somepassword", "", "", s2.URL, nil, plex.WithCache(cache, time.Hour))
+	c2.HTTPClient.Transport = http.DefaultTransport
+	ctx := context.Background()
+
+	// each client gets its own server's response, rather than the one cached by the other client
+	movies, err := c1.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, []plex.Movie{{Title: "foo"}}, movies)
+	movies, err = c2.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, []plex.Movie{{Title: "bar"}}, movies)
+}
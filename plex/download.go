@@ -0,0 +1,84 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// DownloadPart downloads the media file of a MediaPart, using its Key (e.g. "/library/parts/1/1234567890/file.mkv").
+// If offset is not zero, the download starts at that offset, allowing an interrupted download to be resumed.
+//
+// DownloadPart returns the file's content and the number of bytes that will be returned (or -1 if unknown).
+// The caller must close the returned io.ReadCloser.
+func (c *Client) DownloadPart(ctx context.Context, partKey string, offset int64) (io.ReadCloser, int64, error) {
+	return c.downloadPart(ctx, partKey, offset, -1)
+}
+
+// DownloadPartRange downloads the bytes from start to end (inclusive) of a MediaPart's media file. If the server
+// ignores the requested range and returns the full file, DownloadPartRange skips the bytes outside the range.
+//
+// DownloadPartRange returns the content and the number of bytes that will be returned (or -1 if unknown).
+// The caller must close the returned io.ReadCloser.
+func (c *Client) DownloadPartRange(ctx context.Context, partKey string, start, end int64) (io.ReadCloser, int64, error) {
+	if start < 0 || end < start {
+		return nil, 0, fmt.Errorf("invalid range: %d-%d", start, end)
+	}
+	return c.downloadPart(ctx, partKey, start, end)
+}
+
+// downloadPart downloads the bytes from start to end (inclusive). If end is negative, it downloads until the end of the file.
+func (c *Client) downloadPart(ctx context.Context, partKey string, start, end int64) (io.ReadCloser, int64, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, partKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if start > 0 || end >= 0 {
+		byteRange := "bytes=" + strconv.FormatInt(start, 10) + "-"
+		if end >= 0 {
+			byteRange += strconv.FormatInt(end, 10)
+		}
+		req.Header.Set("Range", byteRange)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, resp.ContentLength, nil
+	case http.StatusOK:
+		// server ignored the range (or none was requested): skip/limit the content ourselves
+		return skipToRange(resp, start, end)
+	default:
+		_ = resp.Body.Close()
+		return nil, 0, errors.New(resp.Status)
+	}
+}
+
+func skipToRange(resp *http.Response, start, end int64) (io.ReadCloser, int64, error) {
+	if start > 0 {
+		if _, err := io.CopyN(io.Discard, resp.Body, start); err != nil {
+			_ = resp.Body.Close()
+			return nil, 0, fmt.Errorf("skip: %w", err)
+		}
+	}
+	size := resp.ContentLength
+	if size >= 0 {
+		size -= start
+	}
+	if end < 0 {
+		return resp.Body, size, nil
+	}
+	if want := end - start + 1; size < 0 || want < size {
+		size = want
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{Reader: io.LimitReader(resp.Body, size), Closer: resp.Body}, size, nil
+}
@@ -0,0 +1,135 @@
+package plex_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var partContent = []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+
+func partServer() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/library/parts/1/1234/file.mkv" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "file.mkv", time.Time{}, bytes.NewReader(partContent))
+	})
+}
+
+func TestClient_DownloadPart(t *testing.T) {
+	c, s := makeClientAndServer(partServer())
+	defer s.Close()
+
+	tests := []struct {
+		name    string
+		partKey string
+		offset  int64
+		wantErr assert.ErrorAssertionFunc
+		want    []byte
+	}{
+		{
+			name:    "full",
+			partKey: "/library/parts/1/1234/file.mkv",
+			wantErr: assert.NoError,
+			want:    partContent,
+		},
+		{
+			name:    "offset",
+			partKey: "/library/parts/1/1234/file.mkv",
+			offset:  10,
+			wantErr: assert.NoError,
+			want:    partContent[10:],
+		},
+		{
+			name:    "not found",
+			partKey: "/library/parts/1/1234/missing.mkv",
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, size, err := c.DownloadPart(context.Background(), tt.partKey, tt.offset)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			defer func() { _ = body.Close() }()
+			content, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, content)
+			assert.Equal(t, int64(len(tt.want)), size)
+		})
+	}
+}
+
+func TestClient_DownloadPartRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		ignoreRange bool
+		start       int64
+		end         int64
+		wantErr     assert.ErrorAssertionFunc
+		want        []byte
+	}{
+		{
+			name:    "range",
+			start:   10,
+			end:     19,
+			wantErr: assert.NoError,
+			want:    partContent[10:20],
+		},
+		{
+			name:        "range ignored",
+			ignoreRange: true,
+			start:       10,
+			end:         19,
+			wantErr:     assert.NoError,
+			want:        partContent[10:20],
+		},
+		{
+			name:        "range ignored, beyond end of file",
+			ignoreRange: true,
+			start:       30,
+			end:         99,
+			wantErr:     assert.NoError,
+			want:        partContent[30:],
+		},
+		{
+			name:    "invalid range",
+			start:   20,
+			end:     10,
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := partServer()
+			if tt.ignoreRange {
+				h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					r.Header.Del("Range")
+					partServer().ServeHTTP(w, r)
+				})
+			}
+			c, s := makeClientAndServer(h)
+			defer s.Close()
+
+			body, size, err := c.DownloadPartRange(context.Background(), "/library/parts/1/1234/file.mkv", tt.start, tt.end)
+			tt.wantErr(t, err)
+			if err != nil {
+				return
+			}
+			defer func() { _ = body.Close() }()
+			content, err := io.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, content)
+			assert.Equal(t, int64(len(tt.want)), size)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package plex
+
+import "strings"
+
+// ExternalIDs contains the IDs of an item in external metadata databases
+type ExternalIDs struct {
+	IMDB string
+	TMDB string
+	TVDB string
+}
+
+// ParseGUIDs extracts the external IDs from a set of Plex GUIDs. It supports both the current format
+// (e.g. "imdb://tt0111161", "tmdb://278", "tvdb://81189") and the legacy agent format
+// (e.g. "com.plexapp.agents.imdb://tt0111161?lang=en"). Plex' own GUIDs ("plex://movie/...") are ignored.
+func ParseGUIDs(guids ...string) ExternalIDs {
+	var ids ExternalIDs
+	for _, guid := range guids {
+		scheme, id, ok := strings.Cut(guid, "://")
+		if !ok {
+			continue
+		}
+		// legacy agents append the language as a query parameter. tv episodes also append the season & episode.
+		id, _, _ = strings.Cut(id, "?")
+		id, _, _ = strings.Cut(id, "/")
+		switch strings.TrimPrefix(scheme, "com.plexapp.agents.") {
+		case "imdb":
+			ids.IMDB = id
+		case "tmdb", "themoviedb":
+			ids.TMDB = id
+		case "tvdb", "thetvdb":
+			ids.TVDB = id
+		}
+	}
+	return ids
+}
+
+// ExternalIDs returns the movie's IDs in external metadata databases
+func (m Movie) ExternalIDs() ExternalIDs {
+	return ParseGUIDs(guids(m.Guid, m.Guids)...)
+}
+
+// ExternalIDs returns the show's IDs in external metadata databases
+func (s Show) ExternalIDs() ExternalIDs {
+	return ParseGUIDs(guids(s.Guid, s.Guids)...)
+}
+
+func guids(guid string, external []GUID) []string {
+	all := make([]string, 0, 1+len(external))
+	all = append(all, guid)
+	for _, g := range external {
+		all = append(all, g.ID)
+	}
+	return all
+}
@@ -0,0 +1,71 @@
+package plex_test
+
+import (
+	"encoding/json"
+	"github.com/clambin/mediaclients/plex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseGUIDs(t *testing.T) {
+	tests := []struct {
+		name  string
+		guids []string
+		want  plex.ExternalIDs
+	}{
+		{
+			name:  "current format",
+			guids: []string{"plex://movie/5d776825880197001ec967c6", "imdb://tt0111161", "tmdb://278", "tvdb://190"},
+			want:  plex.ExternalIDs{IMDB: "tt0111161", TMDB: "278", TVDB: "190"},
+		},
+		{
+			name:  "legacy imdb",
+			guids: []string{"com.plexapp.agents.imdb://tt0111161?lang=en"},
+			want:  plex.ExternalIDs{IMDB: "tt0111161"},
+		},
+		{
+			name:  "legacy tmdb",
+			guids: []string{"com.plexapp.agents.themoviedb://278?lang=en"},
+			want:  plex.ExternalIDs{TMDB: "278"},
+		},
+		{
+			name:  "legacy tvdb episode",
+			guids: []string{"com.plexapp.agents.thetvdb://81189/1/2?lang=en"},
+			want:  plex.ExternalIDs{TVDB: "81189"},
+		},
+		{
+			name:  "plex only",
+			guids: []string{"plex://show/5d9c086c46115600200aa2fe"},
+			want:  plex.ExternalIDs{},
+		},
+		{
+			name:  "invalid",
+			guids: []string{"", "foo"},
+			want:  plex.ExternalIDs{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, plex.ParseGUIDs(tt.guids...))
+		})
+	}
+}
+
+func TestMovie_ExternalIDs(t *testing.T) {
+	m := plex.Movie{Guid: "com.plexapp.agents.imdb://tt0111161?lang=en"}
+	assert.Equal(t, plex.ExternalIDs{IMDB: "tt0111161"}, m.ExternalIDs())
+}
+
+func TestShow_ExternalIDs(t *testing.T) {
+	const input = `{
+		"guid": "plex://show/5d9c086c46115600200aa2fe",
+		"title": "foo",
+		"Guid": [ { "id": "imdb://tt0903747" }, { "id": "tmdb://1396" }, { "id": "tvdb://81189" } ]
+	}`
+	var s plex.Show
+	require.NoError(t, json.Unmarshal([]byte(input), &s))
+	assert.Equal(t, "plex://show/5d9c086c46115600200aa2fe", s.Guid)
+	assert.Equal(t, []plex.GUID{{ID: "imdb://tt0903747"}, {ID: "tmdb://1396"}, {ID: "tvdb://81189"}}, s.Guids)
+	assert.Equal(t, plex.ExternalIDs{IMDB: "tt0903747", TMDB: "1396", TVDB: "81189"}, s.ExternalIDs())
+}
@@ -1,6 +1,11 @@
 package plex
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
 
 // Identity contains the response of Plex's /identity API
 type Identity struct {
@@ -14,3 +19,12 @@ type Identity struct {
 func (c *Client) GetIdentity(ctx context.Context) (Identity, error) {
 	return call[Identity](ctx, c, "/identity")
 }
+
+// Claim claims an unclaimed Plex Media Server (see Identity.Claimed), linking it to the plex.tv account of the client.
+// claimToken is a claim token, generated at https://plex.tv/claim.
+func (c *Client) Claim(ctx context.Context, claimToken string) error {
+	if err := c.send(ctx, http.MethodPost, "/myplex/claim?"+url.Values{"token": []string{claimToken}}.Encode()); err != nil {
+		return fmt.Errorf("claim: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/http"
 	"testing"
 )
 
@@ -15,3 +16,23 @@ func TestPlexClient_GetIdentity(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "SomeVersion", identity.Version)
 }
+
+func TestClient_Claim(t *testing.T) {
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/myplex/claim" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("token") != "claim-1234" {
+			http.Error(w, "invalid token", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s.Close()
+
+	require.NoError(t, c.Claim(context.Background(), "claim-1234"))
+
+	err := c.Claim(context.Background(), "claim-4321")
+	assert.EqualError(t, err, "claim: 400 Bad Request")
+}
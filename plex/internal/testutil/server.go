@@ -18,6 +18,12 @@ func WithToken(token string, next http.Handler) http.HandlerFunc {
 var TestServer = testutils.TestServer{Paths: plexResponses}
 
 var plexResponses = map[string]testutils.Path{
+	"/": {Body: []byte(`{ "MediaContainer": {
+		"size": 0,
+		"machineIdentifier": "SomeUUID",
+		"version": "SomeVersion"
+	}}`)},
+
 	"/identity": {Body: []byte(`{ "MediaContainer": {
     	"size": 0,
     	"claimed": true,
@@ -28,10 +34,10 @@ var plexResponses = map[string]testutils.Path{
 	"/status/sessions": {Body: []byte(`{ "MediaContainer": {
 		"size": 2,
 		"Metadata": [
-			{ "User": { "title": "foo" },   "Player": { "product": "Plex Web" }, "Session": { "location": "lan"}, "grandparentTitle": "series", "parentTitle": "season 1", "title": "pilot", "type": "episode"},
-			{ "User": { "title": "bar" },   "Player": { "product": "Plex Web" }, "Session": { "location": "wan"}, "TranscodeSession": { "throttled": false, "videoDecision": "copy" }, "title": "movie 1" },
-			{ "User": { "title": "snafu" }, "Player": { "product": "Plex Web" }, "Session": { "location": "lan"}, "TranscodeSession": { "throttled": true, "speed": 3.1, "videoDecision": "transcode" }, "title": "movie 2" },
-			{ "User": { "title": "snafu" }, "Player": { "product": "Plex Web" }, "Session": { "location": "lan"}, "TranscodeSession": { "throttled": true, "speed": 4.1, "videoDecision": "transcode" }, "title": "movie 3" }
+			{ "User": { "title": "foo" }, "sessionKey": "1",   "Player": { "product": "Plex Web" }, "Session": { "location": "lan"}, "grandparentTitle": "series", "parentTitle": "season 1", "title": "pilot", "type": "episode"},
+			{ "User": { "title": "bar" }, "sessionKey": "2",   "Player": { "product": "Plex Web" }, "Session": { "location": "wan"}, "TranscodeSession": { "throttled": false, "videoDecision": "copy" }, "title": "movie 1" },
+			{ "User": { "title": "snafu" }, "sessionKey": "3", "Player": { "product": "Plex Web" }, "Session": { "location": "lan"}, "TranscodeSession": { "throttled": true, "speed": 3.1, "videoDecision": "transcode", "context": "streaming" }, "title": "movie 2" },
+			{ "User": { "title": "snafu" }, "sessionKey": "4", "Player": { "product": "Plex Web" }, "Session": { "location": "lan"}, "TranscodeSession": { "throttled": true, "speed": 4.1, "videoDecision": "transcode", "context": "static" }, "title": "movie 3" }
 		]
 	}}`)},
 
@@ -55,6 +61,39 @@ var plexResponses = map[string]testutils.Path{
         ]
     }}`)},
 
+	"/library/sections/1/genre": {Body: []byte(`{ "MediaContainer" : {
+        "size": 2,
+        "Directory": [
+           { "fastKey": "/library/sections/1/all?genre=1", "key": "1", "title": "Action", "type": "genre" },
+           { "fastKey": "/library/sections/1/all?genre=2", "key": "2", "title": "Comedy", "type": "genre" }
+        ]
+    }}`)},
+
+	"/library/sections/1/collections": {Body: []byte(`{ "MediaContainer" : {
+        "size": 1,
+        "Metadata": [
+           { "ratingKey": "100", "title": "Trilogy", "childCount": 3 }
+        ]
+    }}`)},
+
+	"/library/metadata/202": {Body: []byte(`{ "MediaContainer" : {
+        "size": 1,
+        "Metadata": [
+           { "ratingKey": "202", "title": "Episode 1", "Marker": [
+              { "id": 1, "type": "intro", "startTimeOffset": 1000, "endTimeOffset": 60000 },
+              { "id": 2, "type": "credits", "startTimeOffset": 2400000, "endTimeOffset": 2500000 }
+           ] }
+        ]
+    }}`)},
+
+	"/library/recentlyAdded": {Body: []byte(`{ "MediaContainer" : {
+        "size": 2,
+        "Metadata": [
+           { "ratingKey": "201", "type": "episode", "title": "Episode 1", "grandparentTitle": "series", "parentIndex": 1, "index": 1, "librarySectionID": 2, "librarySectionTitle": "Shows", "addedAt": 1700000100 },
+           { "ratingKey": "100", "type": "movie", "title": "foo", "year": 2020, "librarySectionID": 1, "librarySectionTitle": "Movies", "addedAt": 1700000000 }
+        ]
+    }}`)},
+
 	"/library/metadata/200/children": {Body: []byte(`{ "MediaContainer" : {
         "Metadata": [
            { "guid": "2", "title": "Season 1" }
@@ -2,8 +2,21 @@ package plex
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
 )
 
+// ErrLibraryNotFound indicates that the requested library section does not exist. See WithLibraryNotFound.
+var ErrLibraryNotFound = errors.New("library not found")
+
+// ErrDeletionDisabled indicates that DeleteItem was called on a Client created without WithAllowDeletion.
+var ErrDeletionDisabled = errors.New("deletion disabled")
+
 func (c *Client) GetLibraries(ctx context.Context) ([]Library, error) {
 	type response struct {
 		Directory []Library `json:"Directory"`
@@ -13,35 +26,241 @@ func (c *Client) GetLibraries(ctx context.Context) ([]Library, error) {
 }
 
 func (c *Client) GetMovies(ctx context.Context, key string) ([]Movie, error) {
-	type response struct {
-		Metadata []Movie `json:"Metadata"`
+	return getSectionItems[Movie](ctx, c, "/library/sections/"+key+"/all")
+}
+
+// getSectionItems retrieves the items of a library section. For a section that doesn't exist, Plex returns an empty
+// MediaContainer (rather than an HTTP error), without a librarySectionID. If the Client was created with
+// WithLibraryNotFound, getSectionItems returns ErrLibraryNotFound in that case.
+func getSectionItems[T any](ctx context.Context, c *Client, endpoint string) ([]T, error) {
+	items, container, err := callPagedContainer[T](ctx, c, endpoint)
+	if err == nil && c.libraryNotFound && len(items) == 0 && (len(container.LibrarySectionID) == 0 || string(container.LibrarySectionID) == "null") {
+		err = ErrLibraryNotFound
 	}
-	resp, err := call[response](ctx, c, "/library/sections/"+key+"/all")
-	return resp.Metadata, err
+	return items, err
+}
+
+// LibraryFilter narrows down the items returned by GetMoviesFiltered. Zero values are ignored.
+type LibraryFilter struct {
+	// Unwatched only returns items that haven't been watched yet
+	Unwatched bool
+	// Genre only returns items of the specified genre. This is the Key of the genre's Tag (see GetGenres).
+	Genre string
+	// Year only returns items released in the specified year
+	Year int
+	// Sort sets the sort order, e.g. "addedAt:desc"
+	Sort string
+}
+
+func (f LibraryFilter) values() url.Values {
+	v := make(url.Values)
+	if f.Unwatched {
+		v.Set("unwatched", "1")
+	}
+	if f.Genre != "" {
+		v.Set("genre", f.Genre)
+	}
+	if f.Year != 0 {
+		v.Set("year", strconv.Itoa(f.Year))
+	}
+	if f.Sort != "" {
+		v.Set("sort", f.Sort)
+	}
+	return v
+}
+
+// GetMoviesFiltered returns the movies in the library section with the specified key that match the filter.
+func (c *Client) GetMoviesFiltered(ctx context.Context, sectionKey string, filter LibraryFilter) ([]Movie, error) {
+	endpoint := "/library/sections/" + sectionKey + "/all"
+	if query := filter.values().Encode(); query != "" {
+		endpoint += "?" + query
+	}
+	return getSectionItems[Movie](ctx, c, endpoint)
 }
 
 func (c *Client) GetShows(ctx context.Context, key string) ([]Show, error) {
+	return getSectionItems[Show](ctx, c, "/library/sections/"+key+"/all")
+}
+
+func (c *Client) GetSeasons(ctx context.Context, key string) ([]Season, error) {
+	return callPaged[Season](ctx, c, "/library/metadata/"+key+"/children")
+}
+
+func (c *Client) GetEpisodes(ctx context.Context, key string) ([]Episode, error) {
+	return callPaged[Episode](ctx, c, "/library/metadata/"+key+"/children")
+}
+
+// GetGenres returns the genres used in the library section with the specified key.
+func (c *Client) GetGenres(ctx context.Context, sectionKey string) ([]Tag, error) {
 	type response struct {
-		Metadata []Show `json:"Metadata"`
+		Directory []Tag `json:"Directory"`
 	}
-	resp, err := call[response](ctx, c, "/library/sections/"+key+"/all")
-	return resp.Metadata, err
+	resp, err := call[response](ctx, c, "/library/sections/"+sectionKey+"/genre")
+	return resp.Directory, err
 }
 
-func (c *Client) GetSeasons(ctx context.Context, key string) ([]Season, error) {
+// GetCollections returns the collections in the library section with the specified key.
+func (c *Client) GetCollections(ctx context.Context, sectionKey string) ([]Collection, error) {
+	return callPaged[Collection](ctx, c, "/library/sections/"+sectionKey+"/collections")
+}
+
+// GetAllRecentlyAdded returns the items most recently added to any library of the server, most recent first.
+// The result contains up to limit items. If limit is zero or negative, all items are returned.
+func (c *Client) GetAllRecentlyAdded(ctx context.Context, limit int) ([]Metadata, error) {
+	if limit <= 0 {
+		return callPaged[Metadata](ctx, c, "/library/recentlyAdded")
+	}
 	type response struct {
-		Metadata []Season `json:"Metadata"`
+		Metadata []Metadata `json:"Metadata"`
 	}
-	resp, err := call[response](ctx, c, "/library/metadata/"+key+"/children")
+	headers := make(http.Header)
+	headers.Set("X-Plex-Container-Start", "0")
+	headers.Set("X-Plex-Container-Size", strconv.Itoa(limit))
+	resp, err := callWithHeaders[response](ctx, c, "/library/recentlyAdded", headers)
 	return resp.Metadata, err
 }
 
-func (c *Client) GetEpisodes(ctx context.Context, key string) ([]Episode, error) {
+// GetMarkers returns the markers (e.g. intro, credits) of the item with the specified rating key.
+func (c *Client) GetMarkers(ctx context.Context, ratingKey string) ([]Marker, error) {
 	type response struct {
-		Metadata []Episode `json:"Metadata"`
+		Metadata []struct {
+			Marker []Marker `json:"Marker"`
+		} `json:"Metadata"`
+	}
+	resp, err := call[response](ctx, c, "/library/metadata/"+ratingKey+"?includeMarkers=1")
+	if err != nil || len(resp.Metadata) == 0 {
+		return nil, err
+	}
+	return resp.Metadata[0].Marker, nil
+}
+
+// RefreshMetadata refreshes the metadata of the item with the specified rating key, using the library's metadata agent.
+func (c *Client) RefreshMetadata(ctx context.Context, ratingKey string) error {
+	if err := c.send(ctx, http.MethodPut, "/library/metadata/"+ratingKey+"/refresh"); err != nil {
+		return fmt.Errorf("refresh: %w", err)
+	}
+	return nil
+}
+
+// MarkWatched marks the item with the specified rating key as watched.
+func (c *Client) MarkWatched(ctx context.Context, ratingKey string) error {
+	if err := c.send(ctx, http.MethodGet, "/:/scrobble?"+libraryItemQuery(ratingKey).Encode()); err != nil {
+		return fmt.Errorf("scrobble: %w", err)
+	}
+	return nil
+}
+
+// MarkUnwatched marks the item with the specified rating key as unwatched.
+func (c *Client) MarkUnwatched(ctx context.Context, ratingKey string) error {
+	if err := c.send(ctx, http.MethodGet, "/:/unscrobble?"+libraryItemQuery(ratingKey).Encode()); err != nil {
+		return fmt.Errorf("unscrobble: %w", err)
+	}
+	return nil
+}
+
+// SetRating sets the user rating of the item with the specified rating key. rating is expressed in stars (0 to 5,
+// in steps of half a star). Plex stores ratings on a 0 to 10 scale (see Movie.UserRating).
+func (c *Client) SetRating(ctx context.Context, ratingKey string, rating float64) error {
+	if math.IsNaN(rating) || rating < 0 || rating > 5 {
+		return fmt.Errorf("invalid rating %v: must be between 0 and 5", rating)
+	}
+	if rating*2 != math.Trunc(rating*2) {
+		return fmt.Errorf("invalid rating %v: must be a multiple of 0.5", rating)
+	}
+	query := libraryItemQuery(ratingKey)
+	query.Set("rating", strconv.FormatFloat(rating*2, 'f', -1, 64))
+	if err := c.send(ctx, http.MethodPut, "/:/rate?"+query.Encode()); err != nil {
+		return fmt.Errorf("rate: %w", err)
+	}
+	return nil
+}
+
+// DeleteItem deletes the item with the specified rating key, including its media files, from the Plex Media Server.
+// As this cannot be undone, the Client must be created with WithAllowDeletion. Otherwise, DeleteItem returns ErrDeletionDisabled.
+func (c *Client) DeleteItem(ctx context.Context, ratingKey string) error {
+	if !c.allowDeletion {
+		return ErrDeletionDisabled
+	}
+	if err := c.send(ctx, http.MethodDelete, "/library/metadata/"+ratingKey); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	return nil
+}
+
+// AddToCollection adds the item with the specified rating key to the collection with the specified title.
+// If the collection does not exist, Plex creates it.
+func (c *Client) AddToCollection(ctx context.Context, ratingKey, collectionTitle string) error {
+	return c.editCollection(ctx, ratingKey, collectionTitle, false)
+}
+
+// RemoveFromCollection removes the item with the specified rating key from the collection with the specified title.
+func (c *Client) RemoveFromCollection(ctx context.Context, ratingKey, collectionTitle string) error {
+	return c.editCollection(ctx, ratingKey, collectionTitle, true)
+}
+
+// metadataTypes maps the type of item to the type number that Plex expects when editing an item's tags.
+var metadataTypes = map[string]string{
+	"movie":   "1",
+	"show":    "2",
+	"season":  "3",
+	"episode": "4",
+	"artist":  "8",
+	"album":   "9",
+	"track":   "10",
+}
+
+func (c *Client) editCollection(ctx context.Context, ratingKey, collectionTitle string, remove bool) error {
+	// tag edits are sent to the item's library section, so we need to look up the item first
+	type response struct {
+		Metadata []struct {
+			LibrarySectionID json.Number `json:"librarySectionID"`
+			Type             string      `json:"type"`
+			Collection       []struct {
+				Tag string `json:"tag"`
+			} `json:"Collection"`
+		} `json:"Metadata"`
+	}
+	resp, err := call[response](ctx, c, "/library/metadata/"+ratingKey)
+	if err != nil {
+		return fmt.Errorf("collection: %w", err)
+	}
+	if len(resp.Metadata) == 0 {
+		return fmt.Errorf("collection: item %s not found", ratingKey)
+	}
+	item := resp.Metadata[0]
+	typ, ok := metadataTypes[item.Type]
+	if !ok {
+		return fmt.Errorf("collection: unsupported item type %q", item.Type)
+	}
+	query := url.Values{
+		"type": []string{typ},
+		"id":   []string{ratingKey},
+	}
+	if remove {
+		query.Set("collection[].tag.tag-", collectionTitle)
+	} else {
+		// setting tags replaces the item's existing tags, so we send the collections the item is already in as well
+		collections := make([]string, 0, len(item.Collection)+1)
+		for _, collection := range item.Collection {
+			if collection.Tag != collectionTitle {
+				collections = append(collections, collection.Tag)
+			}
+		}
+		for i, collection := range append(collections, collectionTitle) {
+			query.Set("collection["+strconv.Itoa(i)+"].tag.tag", collection)
+		}
+	}
+	if err = c.send(ctx, http.MethodPut, "/library/sections/"+item.LibrarySectionID.String()+"/all?"+query.Encode()); err != nil {
+		return fmt.Errorf("collection: %w", err)
+	}
+	return nil
+}
+
+func libraryItemQuery(ratingKey string) url.Values {
+	return url.Values{
+		"identifier": []string{"com.plexapp.plugins.library"},
+		"key":        []string{ratingKey},
 	}
-	resp, err := call[response](ctx, c, "/library/metadata/"+key+"/children")
-	return resp.Metadata, err
 }
 
 /*
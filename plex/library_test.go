@@ -2,13 +2,18 @@ package plex_test
 
 import (
 	"context"
+	"fmt"
 	"github.com/clambin/mediaclients/plex"
 	"github.com/clambin/mediaclients/plex/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
+	"time"
 )
 
 func TestClient_GetLibraries(t *testing.T) {
@@ -35,6 +40,50 @@ func TestClient_GetMovies(t *testing.T) {
 	assert.Equal(t, []plex.Movie{{Guid: "1", Title: "foo"}}, movies)
 }
 
+func TestClient_GetMoviesFiltered(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter plex.LibraryFilter
+		want   string
+	}{
+		{
+			name: "none",
+			want: "",
+		},
+		{
+			name:   "unwatched",
+			filter: plex.LibraryFilter{Unwatched: true},
+			want:   "unwatched=1",
+		},
+		{
+			name:   "genre & year",
+			filter: plex.LibraryFilter{Genre: "1", Year: 2024},
+			want:   "genre=1&year=2024",
+		},
+		{
+			name:   "all",
+			filter: plex.LibraryFilter{Unwatched: true, Genre: "1", Year: 2024, Sort: "addedAt:desc"},
+			want:   "genre=1&sort=addedAt%3Adesc&unwatched=1&year=2024",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.RawQuery
+				testutil.TestServer.ServeHTTP(w, r)
+			}))
+			defer s.Close()
+
+			movies, err := c.GetMoviesFiltered(context.Background(), "1", tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, []plex.Movie{{Guid: "1", Title: "foo"}}, movies)
+			assert.Equal(t, tt.want, query)
+		})
+	}
+}
+
 func TestClient_GetShows(t *testing.T) {
 	c, s := makeClientAndServer(nil)
 	defer s.Close()
@@ -61,3 +110,360 @@ func TestClient_GetEpisodes(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, []plex.Episode{{Guid: "2", Title: "Episode 1"}}, shows)
 }
+
+func TestClient_GetGenres(t *testing.T) {
+	c, s := makeClientAndServer(nil)
+	defer s.Close()
+
+	genres, err := c.GetGenres(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, []plex.Tag{
+		{FastKey: "/library/sections/1/all?genre=1", Key: "1", Title: "Action", Type: "genre"},
+		{FastKey: "/library/sections/1/all?genre=2", Key: "2", Title: "Comedy", Type: "genre"},
+	}, genres)
+}
+
+func TestClient_GetCollections(t *testing.T) {
+	c, s := makeClientAndServer(nil)
+	defer s.Close()
+
+	collections, err := c.GetCollections(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, []plex.Collection{{RatingKey: "100", Title: "Trilogy", ChildCount: 3}}, collections)
+}
+
+func TestWithContainerSize(t *testing.T) {
+	movies := []string{"foo", "bar", "snafu"}
+	var requests []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.Header.Get("X-Plex-Container-Start"))
+		size, _ := strconv.Atoi(r.Header.Get("X-Plex-Container-Size"))
+		requests = append(requests, fmt.Sprintf("%d/%d", start, size))
+		end := min(start+size, len(movies))
+		var metadata string
+		for i, title := range movies[start:end] {
+			if i > 0 {
+				metadata += ","
+			}
+			metadata += `{ "title": "` + title + `" }`
+		}
+		_, _ = fmt.Fprintf(w, `{ "MediaContainer": { "size": %d, "offset": %d, "totalSize": %d, "Metadata": [ %s ] } }`, end-start, start, len(movies), metadata)
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name         string
+		options      []plex.Option
+		wantRequests []string
+	}{
+		{
+			name:         "default",
+			wantRequests: []string{"0/100"},
+		},
+		{
+			name:         "paged",
+			options:      []plex.Option{plex.WithContainerSize(2)},
+			wantRequests: []string{"0/2", "2/2"},
+		},
+		{
+			name:         "exact",
+			options:      []plex.Option{plex.WithContainerSize(3)},
+			wantRequests: []string{"0/3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, tt.options...)
+			c.HTTPClient.Transport = http.DefaultTransport
+
+			got, err := c.GetMovies(context.Background(), "1")
+			require.NoError(t, err)
+			assert.Equal(t, []plex.Movie{{Title: "foo"}, {Title: "bar"}, {Title: "snafu"}}, got)
+			assert.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
+
+func TestWithContainerSize_PagingIgnored(t *testing.T) {
+	// the server ignores the paging headers and always returns the same movies
+	tests := []struct {
+		name         string
+		response     string
+		want         []plex.Movie
+		wantRequests int
+	}{
+		{
+			name:         "full list",
+			response:     `{ "MediaContainer": { "size": 3, "Metadata": [ { "title": "foo" }, { "title": "bar" }, { "title": "snafu" } ] } }`,
+			want:         []plex.Movie{{Title: "foo"}, {Title: "bar"}, {Title: "snafu"}},
+			wantRequests: 1,
+		},
+		{
+			name:         "no totalSize",
+			response:     `{ "MediaContainer": { "size": 2, "Metadata": [ { "title": "foo" }, { "title": "bar" } ] } }`,
+			want:         []plex.Movie{{Title: "foo"}, {Title: "bar"}},
+			wantRequests: 1,
+		},
+		{
+			name:         "wrong offset",
+			response:     `{ "MediaContainer": { "size": 2, "offset": 0, "totalSize": 4, "Metadata": [ { "title": "foo" }, { "title": "bar" } ] } }`,
+			want:         []plex.Movie{{Title: "foo"}, {Title: "bar"}},
+			wantRequests: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer s.Close()
+
+			c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithContainerSize(2))
+			c.HTTPClient.Transport = http.DefaultTransport
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			got, err := c.GetMovies(ctx, "1")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantRequests, requests)
+		})
+	}
+}
+
+func TestClient_GetMovies_LibraryNotFound(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/library/sections/1/all":
+			_, _ = w.Write([]byte(`{ "MediaContainer": { "size": 0, "librarySectionID": 1, "librarySectionTitle": "Movies" } }`))
+		default:
+			_, _ = w.Write([]byte(`{ "MediaContainer": { "size": 0 } }`))
+		}
+	}))
+	defer s.Close()
+
+	// by default, a missing library is returned as an empty list
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.HTTPClient.Transport = http.DefaultTransport
+	movies, err := c.GetMovies(context.Background(), "99")
+	require.NoError(t, err)
+	assert.Empty(t, movies)
+
+	c = plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithLibraryNotFound())
+	c.HTTPClient.Transport = http.DefaultTransport
+
+	movies, err = c.GetMovies(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Empty(t, movies)
+
+	_, err = c.GetMovies(context.Background(), "99")
+	assert.ErrorIs(t, err, plex.ErrLibraryNotFound)
+
+	_, err = c.GetShows(context.Background(), "99")
+	assert.ErrorIs(t, err, plex.ErrLibraryNotFound)
+}
+
+func TestClient_GetMarkers(t *testing.T) {
+	var query string
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		testutil.TestServer.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+
+	markers, err := c.GetMarkers(context.Background(), "202")
+	require.NoError(t, err)
+	assert.Equal(t, "includeMarkers=1", query)
+	assert.Equal(t, []plex.Marker{
+		{Id: 1, Type: "intro", StartTimeOffset: 1000, EndTimeOffset: 60000},
+		{Id: 2, Type: "credits", StartTimeOffset: 2400000, EndTimeOffset: 2500000},
+	}, markers)
+
+	_, err = c.GetMarkers(context.Background(), "999")
+	assert.Error(t, err)
+}
+
+func TestClient_RefreshMetadata(t *testing.T) {
+	var method, path string
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		if path != "/library/metadata/100/refresh" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	require.NoError(t, c.RefreshMetadata(context.Background(), "100"))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/library/metadata/100/refresh", path)
+
+	assert.EqualError(t, c.RefreshMetadata(context.Background(), "999"), "refresh: 404 Not Found")
+}
+
+func TestClient_MarkWatched(t *testing.T) {
+	var path string
+	var query url.Values
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		if query.Get("key") != "100" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	require.NoError(t, c.MarkWatched(context.Background(), "100"))
+	assert.Equal(t, "/:/scrobble", path)
+	assert.Equal(t, url.Values{"identifier": {"com.plexapp.plugins.library"}, "key": {"100"}}, query)
+
+	require.NoError(t, c.MarkUnwatched(context.Background(), "100"))
+	assert.Equal(t, "/:/unscrobble", path)
+	assert.Equal(t, url.Values{"identifier": {"com.plexapp.plugins.library"}, "key": {"100"}}, query)
+
+	assert.EqualError(t, c.MarkWatched(context.Background(), "999"), "scrobble: 404 Not Found")
+	assert.EqualError(t, c.MarkUnwatched(context.Background(), "999"), "unscrobble: 404 Not Found")
+}
+
+func TestClient_SetRating(t *testing.T) {
+	var method, path string
+	var query url.Values
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, query = r.Method, r.URL.Path, r.URL.Query()
+	}))
+	defer s.Close()
+
+	tests := []struct {
+		name    string
+		rating  float64
+		wantErr assert.ErrorAssertionFunc
+		want    string
+	}{
+		{name: "zero", rating: 0, wantErr: assert.NoError, want: "0"},
+		{name: "half", rating: 2.5, wantErr: assert.NoError, want: "5"},
+		{name: "max", rating: 5, wantErr: assert.NoError, want: "10"},
+		{name: "negative", rating: -1, wantErr: assert.Error},
+		{name: "too high", rating: 5.5, wantErr: assert.Error},
+		{name: "not a number", rating: math.NaN(), wantErr: assert.Error},
+		{name: "not a half star", rating: 2.3, wantErr: assert.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query = nil
+			err := c.SetRating(context.Background(), "100", tt.rating)
+			tt.wantErr(t, err)
+			if err != nil {
+				assert.Nil(t, query)
+				return
+			}
+			assert.Equal(t, http.MethodPut, method)
+			assert.Equal(t, "/:/rate", path)
+			assert.Equal(t, url.Values{"identifier": {"com.plexapp.plugins.library"}, "key": {"100"}, "rating": {tt.want}}, query)
+		})
+	}
+}
+
+func TestClient_DeleteItem(t *testing.T) {
+	var method, path string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		if path != "/library/metadata/100" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	t.Run("disabled", func(t *testing.T) {
+		c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil)
+		c.HTTPClient.Transport = http.DefaultTransport
+
+		assert.ErrorIs(t, c.DeleteItem(context.Background(), "100"), plex.ErrDeletionDisabled)
+		assert.Empty(t, path)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithAllowDeletion())
+		c.HTTPClient.Transport = http.DefaultTransport
+
+		require.NoError(t, c.DeleteItem(context.Background(), "100"))
+		assert.Equal(t, http.MethodDelete, method)
+		assert.Equal(t, "/library/metadata/100", path)
+
+		assert.EqualError(t, c.DeleteItem(context.Background(), "999"), "delete: 404 Not Found")
+	})
+}
+
+func TestClient_AddToCollection(t *testing.T) {
+	var method, path string
+	var query url.Values
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/library/metadata/100":
+			_, _ = w.Write([]byte(`{ "MediaContainer": { "Metadata": [ { "ratingKey": "100", "librarySectionID": 1, "type": "movie" } ] } }`))
+		case "/library/metadata/101":
+			_, _ = w.Write([]byte(`{ "MediaContainer": { "Metadata": [ { "ratingKey": "101", "librarySectionID": 3, "type": "photo" } ] } }`))
+		case "/library/metadata/102":
+			_, _ = w.Write([]byte(`{ "MediaContainer": { "Metadata": [ { "ratingKey": "102", "librarySectionID": 1, "type": "movie", "Collection": [ { "tag": "Favourites" }, { "tag": "Marvel" } ] } ] } }`))
+		default:
+			method, path, query = r.Method, r.URL.Path, r.URL.Query()
+		}
+	}))
+	defer s.Close()
+
+	ctx := context.Background()
+	require.NoError(t, c.AddToCollection(ctx, "100", "Marvel"))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/library/sections/1/all", path)
+	assert.Equal(t, url.Values{"type": {"1"}, "id": {"100"}, "collection[0].tag.tag": {"Marvel"}}, query)
+
+	require.NoError(t, c.RemoveFromCollection(ctx, "100", "Marvel"))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/library/sections/1/all", path)
+	assert.Equal(t, url.Values{"type": {"1"}, "id": {"100"}, "collection[].tag.tag-": {"Marvel"}}, query)
+
+	// the item's existing collections are kept
+	require.NoError(t, c.AddToCollection(ctx, "102", "Avengers"))
+	assert.Equal(t, url.Values{"type": {"1"}, "id": {"102"}, "collection[0].tag.tag": {"Favourites"}, "collection[1].tag.tag": {"Marvel"}, "collection[2].tag.tag": {"Avengers"}}, query)
+
+	// adding the item to a collection it's already in doesn't duplicate the tag
+	require.NoError(t, c.AddToCollection(ctx, "102", "Marvel"))
+	assert.Equal(t, url.Values{"type": {"1"}, "id": {"102"}, "collection[0].tag.tag": {"Favourites"}, "collection[1].tag.tag": {"Marvel"}}, query)
+
+	assert.EqualError(t, c.AddToCollection(ctx, "101", "Marvel"), `collection: unsupported item type "photo"`)
+}
+
+func TestClient_GetAllRecentlyAdded(t *testing.T) {
+	c, s := makeClientAndServer(nil)
+	defer s.Close()
+
+	items, err := c.GetAllRecentlyAdded(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "episode", items[0].Type)
+	assert.Equal(t, "series", items[0].GrandparentTitle)
+	assert.Equal(t, 1, items[0].ParentIndex)
+	assert.Equal(t, "Shows", items[0].LibrarySectionTitle)
+	assert.Equal(t, "movie", items[1].Type)
+	assert.Equal(t, 2020, items[1].Year)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), time.Time(items[1].AddedAt))
+
+	items, err = c.GetAllRecentlyAdded(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+func TestClient_GetAllRecentlyAdded_Limit(t *testing.T) {
+	var start, size string
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, size = r.Header.Get("X-Plex-Container-Start"), r.Header.Get("X-Plex-Container-Size")
+		_, _ = w.Write([]byte(`{ "MediaContainer": { "size": 0 } }`))
+	}))
+	defer s.Close()
+
+	_, err := c.GetAllRecentlyAdded(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, "0", start)
+	assert.Equal(t, "5", size)
+}
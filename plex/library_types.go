@@ -1,5 +1,10 @@
 package plex
 
+import (
+	"strconv"
+	"strings"
+)
+
 type Library struct {
 	AllowSync        bool      `json:"allowSync"`
 	Art              string    `json:"art"`
@@ -52,6 +57,7 @@ type Movie struct {
 	PrimaryExtraKey       string    `json:"primaryExtraKey,omitempty"`
 	RatingImage           string    `json:"ratingImage,omitempty"`
 	Media                 []Media   `json:"Media"`
+	Guids                 []GUID    `json:"Guid,omitempty"`
 	Genre                 []struct {
 		Tag string `json:"tag"`
 	} `json:"Genre,omitempty"`
@@ -94,6 +100,30 @@ type Media struct {
 	Part                  []MediaPart `json:"Part"`
 }
 
+// ResolutionLabel returns the media's video resolution as a label, e.g. "2160p" (for "4k"), "1080p" or "SD".
+func (m Media) ResolutionLabel() string {
+	return resolutionLabel(m.VideoResolution)
+}
+
+// resolutionLabel normalizes the different ways Plex reports a video resolution ("4k", "1080", "sd", ...).
+func resolutionLabel(resolution string) string {
+	resolution = strings.ToLower(resolution)
+	switch resolution {
+	case "":
+		return ""
+	case "sd":
+		return "SD"
+	case "4k":
+		return "2160p"
+	case "8k":
+		return "4320p"
+	}
+	if _, err := strconv.Atoi(resolution); err == nil {
+		return resolution + "p"
+	}
+	return resolution
+}
+
 type MediaPart struct {
 	Id                    int    `json:"id"`
 	Key                   string `json:"key"`
@@ -134,6 +164,7 @@ type Show struct {
 	UpdatedAt             Timestamp `json:"updatedAt"`
 	AudienceRatingImage   string    `json:"audienceRatingImage"`
 	PrimaryExtraKey       string    `json:"primaryExtraKey,omitempty"`
+	Guids                 []GUID    `json:"Guid,omitempty"`
 	Genre                 []struct {
 		Tag string `json:"tag"`
 	} `json:"Genre"`
@@ -237,3 +268,62 @@ type Episode struct {
 		Tag string `json:"tag"`
 	} `json:"Role"`
 }
+
+// GUID is an item's ID in an external metadata database, e.g. "imdb://tt0111161"
+type GUID struct {
+	ID string `json:"id"`
+}
+
+// Tag is a facet (e.g. a genre) of a library section
+type Tag struct {
+	FastKey string `json:"fastKey"`
+	Key     string `json:"key"`
+	Title   string `json:"title"`
+	Type    string `json:"type"`
+}
+
+// Collection is a collection in a library section
+type Collection struct {
+	RatingKey  string    `json:"ratingKey"`
+	Key        string    `json:"key"`
+	Guid       string    `json:"guid"`
+	Type       string    `json:"type"`
+	Title      string    `json:"title"`
+	Subtype    string    `json:"subtype"`
+	Summary    string    `json:"summary"`
+	ChildCount int       `json:"childCount"`
+	Thumb      string    `json:"thumb"`
+	AddedAt    Timestamp `json:"addedAt"`
+	UpdatedAt  Timestamp `json:"updatedAt"`
+}
+
+// Marker marks a section of an item, e.g. its intro or credits. Offsets are in milliseconds.
+type Marker struct {
+	Id              int    `json:"id"`
+	Type            string `json:"type"`
+	StartTimeOffset int    `json:"startTimeOffset"`
+	EndTimeOffset   int    `json:"endTimeOffset"`
+}
+
+// Metadata is a library item of any type (e.g. a movie, a show, a season or an episode), as returned by
+// endpoints that mix item types. Type indicates the type of item. Attributes that don't apply to the item's type are blank.
+type Metadata struct {
+	RatingKey            string    `json:"ratingKey"`
+	Key                  string    `json:"key"`
+	ParentRatingKey      string    `json:"parentRatingKey,omitempty"`
+	GrandparentRatingKey string    `json:"grandparentRatingKey,omitempty"`
+	Guid                 string    `json:"guid"`
+	Type                 string    `json:"type"`
+	Title                string    `json:"title"`
+	ParentTitle          string    `json:"parentTitle,omitempty"`
+	GrandparentTitle     string    `json:"grandparentTitle,omitempty"`
+	Index                int       `json:"index,omitempty"`
+	ParentIndex          int       `json:"parentIndex,omitempty"`
+	LibrarySectionID     int       `json:"librarySectionID"`
+	LibrarySectionTitle  string    `json:"librarySectionTitle"`
+	Summary              string    `json:"summary"`
+	Year                 int       `json:"year,omitempty"`
+	Thumb                string    `json:"thumb"`
+	AddedAt              Timestamp `json:"addedAt"`
+	UpdatedAt            Timestamp `json:"updatedAt"`
+}
@@ -0,0 +1,234 @@
+package plex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/clambin/mediaclients/internal/backoff"
+	"github.com/coder/websocket"
+	"net/url"
+	"time"
+)
+
+// NotificationsURL returns the URL of the Plex Media Server's notifications WebSocket, including the authentication token.
+// Since it includes the token, the URL should not be logged.
+func (c *Client) NotificationsURL(ctx context.Context) (string, error) {
+	target, err := url.Parse(c.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	switch target.Scheme {
+	case "http":
+		target.Scheme = "ws"
+	case "https":
+		target.Scheme = "wss"
+	default:
+		return "", fmt.Errorf("invalid url: unsupported scheme %q", target.Scheme)
+	}
+	token, err := c.GetAuthToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	target = target.JoinPath("/:/websockets/notifications")
+	target.RawQuery = url.Values{"X-Plex-Token": []string{token}}.Encode()
+	return target.String(), nil
+}
+
+// Notification is an event sent by the Plex Media Server. Type determines which of the lists is populated.
+type Notification struct {
+	Type                         string                         `json:"type"`
+	Size                         int                            `json:"size"`
+	PlaySessionStateNotification []PlaySessionStateNotification `json:"PlaySessionStateNotification,omitempty"`
+	ActivityNotification         []ActivityNotification         `json:"ActivityNotification,omitempty"`
+	TimelineEntry                []TimelineEntry                `json:"TimelineEntry,omitempty"`
+	StatusNotification           []StatusNotification           `json:"StatusNotification,omitempty"`
+}
+
+// PlaySessionStateNotification reports a change in the state of a session (Notification type "playing")
+type PlaySessionStateNotification struct {
+	SessionKey       string        `json:"sessionKey"`
+	ClientIdentifier string        `json:"clientIdentifier"`
+	Guid             string        `json:"guid"`
+	RatingKey        string        `json:"ratingKey"`
+	URL              string        `json:"url"`
+	Key              string        `json:"key"`
+	ViewOffset       int           `json:"viewOffset"`
+	PlayQueueItemID  int           `json:"playQueueItemID"`
+	State            PlaybackState `json:"state"`
+	TranscodeSession string        `json:"transcodeSession,omitempty"`
+}
+
+// PlaybackState is the state of a session, as reported by a PlaySessionStateNotification
+type PlaybackState string
+
+const (
+	PlaybackStatePlaying   PlaybackState = "playing"
+	PlaybackStatePaused    PlaybackState = "paused"
+	PlaybackStateBuffering PlaybackState = "buffering"
+	PlaybackStateStopped   PlaybackState = "stopped"
+)
+
+// ActivityNotification reports the progress of a server activity, e.g. a library scan (Notification type "activity")
+type ActivityNotification struct {
+	Event    string `json:"event"`
+	UUID     string `json:"uuid"`
+	Activity struct {
+		UUID        string `json:"uuid"`
+		Type        string `json:"type"`
+		Cancellable bool   `json:"cancellable"`
+		UserID      int    `json:"userID"`
+		Title       string `json:"title"`
+		Subtitle    string `json:"subtitle"`
+		Progress    int    `json:"progress"`
+	} `json:"Activity"`
+}
+
+// TimelineEntry reports a change to an item in a library (Notification type "timeline")
+type TimelineEntry struct {
+	Identifier    string `json:"identifier"`
+	SectionID     string `json:"sectionID"`
+	ItemID        string `json:"itemID"`
+	Type          int    `json:"type"`
+	Title         string `json:"title"`
+	State         int    `json:"state"`
+	MetadataState string `json:"metadataState,omitempty"`
+	UpdatedAt     int    `json:"updatedAt"`
+}
+
+// StatusNotification reports a server status change (Notification type "status")
+type StatusNotification struct {
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	NotificationName string `json:"notificationName"`
+}
+
+var notificationsBackoff backoff.Backoff = backoff.Exponential{Initial: time.Second, Max: time.Minute, Jitter: 0.2}
+
+// WatchNotifications connects to the Plex Media Server's notifications WebSocket and sends all received notifications
+// to the returned Notification channel. If the connection is lost, WatchNotifications reconnects, with exponential backoff.
+//
+// Errors that occur while watching (e.g. lost connections or undecodable notifications) are sent to the error channel.
+// These are informational only: WatchNotifications keeps running until ctx is cancelled, at which point both channels
+// are closed. If the caller doesn't read the error channel, errors are dropped.
+//
+// WatchNotifications returns an error if it fails to make the initial connection.
+// Closing the Client (see Client.Close) stops watching, as if ctx was cancelled.
+func (c *Client) WatchNotifications(ctx context.Context) (<-chan Notification, <-chan error, error) {
+	conn, err := c.dialNotifications(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	notifications := make(chan Notification)
+	errs := make(chan error, 1)
+	if err = c.goWatch(ctx, func(ctx context.Context) {
+		c.watchNotifications(ctx, conn, notifications, errs)
+	}); err != nil {
+		_ = conn.CloseNow()
+		return nil, nil, err
+	}
+	return notifications, errs, nil
+}
+
+// WatchPlaybackState works like WatchNotifications, but only sends PlaySessionStateNotification events, i.e. sessions
+// that start, pause, resume or stop playing. Errors while watching are not reported.
+// The returned channel is closed when ctx is cancelled.
+func (c *Client) WatchPlaybackState(ctx context.Context) (<-chan PlaySessionStateNotification, error) {
+	notifications, _, err := c.WatchNotifications(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states := make(chan PlaySessionStateNotification)
+	if err = c.goWatch(ctx, func(ctx context.Context) {
+		defer close(states)
+		for notification := range notifications {
+			for _, state := range notification.PlaySessionStateNotification {
+				select {
+				case states <- state:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// notificationsReadLimit is the maximum size of a notification, unless set by WithMaxResponseSize.
+// Activity and timeline notifications can be well over websocket's default limit of 32 KiB.
+const notificationsReadLimit = 4 << 20
+
+func (c *Client) dialNotifications(ctx context.Context) (*websocket.Conn, error) {
+	target, err := c.NotificationsURL(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, _, err := websocket.Dial(ctx, target, &websocket.DialOptions{HTTPClient: c.HTTPClient})
+	if err != nil {
+		return nil, fmt.Errorf("notifications: %w", err)
+	}
+	limit := int64(notificationsReadLimit)
+	if c.maxResponseSize > 0 {
+		limit = c.maxResponseSize
+	}
+	conn.SetReadLimit(limit)
+	return conn, nil
+}
+
+func (c *Client) watchNotifications(ctx context.Context, conn *websocket.Conn, notifications chan<- Notification, errs chan<- error) {
+	defer close(errs)
+	defer close(notifications)
+
+	for {
+		err := readNotifications(ctx, conn, notifications, errs)
+		_ = conn.CloseNow()
+		if ctx.Err() != nil {
+			return
+		}
+		reportError(errs, err)
+
+		conn = nil
+		for attempt := 0; conn == nil; attempt++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(notificationsBackoff.Next(attempt)):
+			}
+			if conn, err = c.dialNotifications(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				reportError(errs, err)
+			}
+		}
+	}
+}
+
+func readNotifications(ctx context.Context, conn *websocket.Conn, notifications chan<- Notification, errs chan<- error) error {
+	for {
+		_, msg, err := conn.Read(ctx)
+		if err != nil {
+			return fmt.Errorf("notifications: %w", err)
+		}
+		var container struct {
+			NotificationContainer Notification `json:"NotificationContainer"`
+		}
+		if err = json.Unmarshal(msg, &container); err != nil {
+			reportError(errs, fmt.Errorf("notifications: decode: %w", err))
+			continue
+		}
+		select {
+		case notifications <- container.NotificationContainer:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// reportError sends the error to the errs channel, unless the channel is full
+func reportError(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
+	}
+}
@@ -0,0 +1,257 @@
+package plex
+
+import (
+	"context"
+	"github.com/clambin/mediaclients/internal/backoff"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_NotificationsURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "http",
+			url:     "http://localhost:32400",
+			want:    "ws://localhost:32400/:/websockets/notifications?X-Plex-Token=some_token",
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "https",
+			url:     "https://plex.example.com/",
+			want:    "wss://plex.example.com/:/websockets/notifications?X-Plex-Token=some_token",
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "invalid scheme",
+			url:     "localhost:32400",
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New("user@example.com", "somepassword", "", "", tt.url, nil)
+			c.SetAuthToken("some_token")
+			got, err := c.NotificationsURL(context.Background())
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+const playingNotification = `{ "NotificationContainer": {
+	"type": "playing",
+	"size": 1,
+	"PlaySessionStateNotification": [ {
+		"sessionKey": "42",
+		"clientIdentifier": "client",
+		"guid": "",
+		"ratingKey": "1234",
+		"url": "",
+		"key": "/library/metadata/1234",
+		"viewOffset": 60000,
+		"playQueueItemID": 10,
+		"state": "playing"
+	} ]
+}}`
+
+// notificationsServer sends a playing notification on each connection. If hangup is true, it then closes the connection.
+func notificationsServer(hangup bool, connections *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/:/websockets/notifications" || r.URL.Query().Get("X-Plex-Token") != "some_token" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		connections.Add(1)
+		defer func() { _ = conn.CloseNow() }()
+		_ = conn.Write(r.Context(), websocket.MessageText, []byte(`not json`))
+		_ = conn.Write(r.Context(), websocket.MessageText, []byte(playingNotification))
+		if hangup {
+			_ = conn.Close(websocket.StatusGoingAway, "bye")
+			return
+		}
+		_, _, _ = conn.Read(r.Context())
+	})
+}
+
+func TestClient_WatchNotifications(t *testing.T) {
+	var connections atomic.Int32
+	s := httptest.NewServer(notificationsServer(false, &connections))
+	defer s.Close()
+
+	c := New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.SetAuthToken("some_token")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notifications, errs, err := c.WatchNotifications(ctx)
+	require.NoError(t, err)
+
+	assert.Error(t, <-errs)
+	n := <-notifications
+	assert.Equal(t, "playing", n.Type)
+	require.Len(t, n.PlaySessionStateNotification, 1)
+	assert.Equal(t, PlaySessionStateNotification{
+		SessionKey:       "42",
+		ClientIdentifier: "client",
+		RatingKey:        "1234",
+		Key:              "/library/metadata/1234",
+		ViewOffset:       60000,
+		PlayQueueItemID:  10,
+		State:            PlaybackStatePlaying,
+	}, n.PlaySessionStateNotification[0])
+
+	cancel()
+	_, ok := <-notifications
+	assert.False(t, ok)
+	assert.Equal(t, int32(1), connections.Load())
+}
+
+func TestClient_WatchNotifications_ReadLimit(t *testing.T) {
+	// a notification larger than websocket's default read limit of 32 KiB
+	largeNotification := playingNotification + strings.Repeat(" ", 64<<10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.CloseNow() }()
+		_ = conn.Write(r.Context(), websocket.MessageText, []byte(largeNotification))
+		_, _, _ = conn.Read(r.Context())
+	}))
+	defer s.Close()
+
+	t.Run("default", func(t *testing.T) {
+		c := New("user@example.com", "somepassword", "", "", s.URL, nil)
+		c.SetAuthToken("some_token")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		notifications, _, err := c.WatchNotifications(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "playing", (<-notifications).Type)
+	})
+
+	t.Run("max response size", func(t *testing.T) {
+		c := New("user@example.com", "somepassword", "", "", s.URL, nil, WithMaxResponseSize(1024))
+		c.SetAuthToken("some_token")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		_, errs, err := c.WatchNotifications(ctx)
+		require.NoError(t, err)
+		assert.Error(t, <-errs)
+	})
+}
+
+func TestClient_WatchNotifications_Reconnect(t *testing.T) {
+	defaultBackoff := notificationsBackoff
+	notificationsBackoff = backoff.Constant{Delay: 10 * time.Millisecond}
+	defer func() { notificationsBackoff = defaultBackoff }()
+
+	var connections atomic.Int32
+	s := httptest.NewServer(notificationsServer(true, &connections))
+	defer s.Close()
+
+	c := New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.SetAuthToken("some_token")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notifications, _, err := c.WatchNotifications(ctx)
+	require.NoError(t, err)
+
+	for range 2 {
+		n := <-notifications
+		assert.Equal(t, "playing", n.Type)
+	}
+	assert.GreaterOrEqual(t, connections.Load(), int32(2))
+
+	// wait for the watcher to stop
+	cancel()
+	for range notifications {
+	}
+}
+
+func TestClient_WatchPlaybackState(t *testing.T) {
+	var connections atomic.Int32
+	s := httptest.NewServer(notificationsServer(false, &connections))
+	defer s.Close()
+
+	c := New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.SetAuthToken("some_token")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	states, err := c.WatchPlaybackState(ctx)
+	require.NoError(t, err)
+
+	state := <-states
+	assert.Equal(t, "42", state.SessionKey)
+	assert.Equal(t, PlaybackStatePlaying, state.State)
+	assert.Equal(t, 60000, state.ViewOffset)
+
+	cancel()
+	for range states {
+	}
+}
+
+func TestClient_WatchNotifications_Failure(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	defer s.Close()
+
+	c := New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.SetAuthToken("some_token")
+
+	_, _, err := c.WatchNotifications(context.Background())
+	assert.Error(t, err)
+}
+
+func TestClient_Close(t *testing.T) {
+	var connections atomic.Int32
+	s := httptest.NewServer(notificationsServer(false, &connections))
+	defer s.Close()
+
+	c := New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.SetAuthToken("some_token")
+
+	before := runtime.NumGoroutine()
+
+	notifications, errs, err := c.WatchNotifications(context.Background())
+	require.NoError(t, err)
+	states, err := c.WatchPlaybackState(context.Background())
+	require.NoError(t, err)
+	// nobody reads states: Close must still be able to stop its goroutine
+	<-notifications
+
+	require.NoError(t, c.Close())
+
+	for range notifications {
+	}
+	for range errs {
+	}
+	for range states {
+	}
+
+	// the server's connection handlers need a moment to notice the client hung up
+	// (note: assert.Eventually runs the condition in a separate goroutine, so we poll ourselves)
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+
+	_, _, err = c.WatchNotifications(context.Background())
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
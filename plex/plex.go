@@ -1,26 +1,183 @@
 package plex
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"golang.org/x/time/rate"
+	"io"
+	"maps"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrResponseTooLarge indicates that the server's response exceeded the size set by WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrCrossHostRedirect indicates that the server redirected the Client to a different host, which WithRedirectPolicy disallowed.
+var ErrCrossHostRedirect = errors.New("redirect to different host")
+
+// ErrClientClosed indicates that the Client was closed (see Client.Close).
+var ErrClientClosed = errors.New("client closed")
+
+// ErrInvalidURL indicates that the Client's URL is not a valid URL of a Plex Media Server (e.g. it has no scheme).
+var ErrInvalidURL = errors.New("invalid url")
+
+// ErrUnauthorized indicates that the client could not authenticate with plex.tv, or that the Plex Media Server rejected its token.
+var ErrUnauthorized = errors.New("unauthorized")
+
 // Client calls the Plex APIs
 type Client struct {
 	URL        string
 	HTTPClient *http.Client
 	*authenticator
+	headers         map[string]string
+	containerSize   int
+	limiter         *rate.Limiter
+	maxResponseSize int64
+	cache           Cache
+	cacheTTL        time.Duration
+	cacheID         string
+	cacheGeneration atomic.Uint64
+	allowDeletion   bool
+	strictDecoding  bool
+	allowUpdates    bool
+	checkRedirect   func(*http.Request, []*http.Request) error
+	libraryNotFound bool
+	watchLock       sync.Mutex
+	watchers        sync.WaitGroup
+	lifetime        context.Context
+	stop            context.CancelFunc
+	closed          bool
+}
+
+// DefaultContainerSize is the default number of items that the Client requests per page, when retrieving
+// large lists (e.g. the movies in a library).
+const DefaultContainerSize = 100
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHeaders sets the specified headers on every request sent to the Plex Media Server.
+// This can be used to pass through reverse proxies / gateways that require additional headers (e.g. Cloudflare Access).
+// The headers are copied: changing the map afterwards doesn't affect the Client.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		c.headers = maps.Clone(headers)
+	}
+}
+
+// WithContainerSize sets the number of items the Client requests per page when retrieving large lists.
+// The default is DefaultContainerSize.
+func WithContainerSize(size int) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.containerSize = size
+		}
+	}
+}
+
+// WithRateLimit limits the rate at which the Client sends requests to the Plex Media Server to rps requests per second,
+// with bursts of up to burst requests. Requests that exceed the rate wait until they're allowed to proceed,
+// or until their context is cancelled. If rps or burst is not positive, the rate is not limited.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		if rps > 0 && burst > 0 {
+			c.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+		}
+	}
+}
+
+// WithMaxResponseSize limits the size of the responses the Client will decode. Responses that are larger
+// return an error wrapping ErrResponseTooLarge. By default, response size is not limited.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) {
+		c.maxResponseSize = size
+	}
+}
+
+// WithAllowDeletion allows the Client to delete media from the Plex Media Server (see DeleteItem).
+// Without this option, DeleteItem returns ErrDeletionDisabled.
+func WithAllowDeletion() Option {
+	return func(c *Client) {
+		c.allowDeletion = true
+	}
+}
+
+// WithAllowUpdates allows the Client to install updates of the Plex Media Server (see ApplyUpdate).
+// Without this option, ApplyUpdate returns ErrUpdatesDisabled.
+func WithAllowUpdates() Option {
+	return func(c *Client) {
+		c.allowUpdates = true
+	}
+}
+
+// WithRedirectPolicy determines how the Client follows redirects (e.g. from a reverse proxy redirecting http to https).
+// The Client follows up to maxRedirects redirects. If allowCrossHost is false, the Client refuses to follow redirects
+// to a different host, returning an error wrapping ErrCrossHostRedirect. Redirects to the same host, but a different
+// scheme or port, are allowed.
+//
+// The Client authenticates every request it sends, including redirected ones, so the token survives redirects.
+// By default, the Client follows up to 10 redirects, to any host. As this sends the token to that host,
+// use WithRedirectPolicy to refuse cross-host redirects when you don't control all hosts involved.
+func WithRedirectPolicy(maxRedirects int, allowCrossHost bool) Option {
+	return func(c *Client) {
+		c.checkRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if !allowCrossHost && req.URL.Hostname() != via[0].URL.Hostname() {
+				return fmt.Errorf("%w: %s", ErrCrossHostRedirect, req.URL.Hostname())
+			}
+			return nil
+		}
+	}
+}
+
+// WithLibraryNotFound makes methods that retrieve the items of a library section (e.g. GetMovies, GetShows) return
+// ErrLibraryNotFound if the section doesn't exist. Plex doesn't report this as an error, but returns an empty list
+// without the section's librarySectionID. Without this option, a missing section is returned as an empty list.
+//
+// Only use this option if the server (and any proxy in between) includes the librarySectionID in its responses:
+// otherwise, every empty library is reported as missing.
+func WithLibraryNotFound() Option {
+	return func(c *Client) {
+		c.libraryNotFound = true
+	}
+}
+
+// WithStrictDecoding makes the Client reject responses that contain attributes it doesn't know about.
+// Since the Client only models a subset of the attributes returned by the Plex Media Server, this is meant for testing
+// against fixtures, to catch changes in the expected schema. By default, unknown attributes are ignored.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
 }
 
-func New(username, password, product, version, url string, roundTripper http.RoundTripper) *Client {
+func New(username, password, product, version, url string, roundTripper http.RoundTripper, options ...Option) *Client {
 	if roundTripper == nil {
 		roundTripper = http.DefaultTransport
 	}
-	auth := &authenticator{
+	c := Client{URL: url, containerSize: DefaultContainerSize}
+	for _, option := range options {
+		option(&c)
+	}
+	if len(c.headers) > 0 {
+		roundTripper = &headerSetter{headers: c.headers, next: roundTripper}
+	}
+	if c.limiter != nil {
+		roundTripper = &rateLimiter{limiter: c.limiter, next: roundTripper}
+	}
+
+	c.authenticator = &authenticator{
 		httpClient: &http.Client{Timeout: 10 * time.Second},
 		username:   username,
 		password:   password,
@@ -29,25 +186,195 @@ func New(username, password, product, version, url string, roundTripper http.Rou
 		version:    version,
 		next:       roundTripper,
 	}
+	c.HTTPClient = &http.Client{Transport: c.authenticator, CheckRedirect: c.checkRedirect}
+	return &c
+}
+
+var _ http.RoundTripper = &headerSetter{}
 
-	return &Client{
-		URL:           url,
-		HTTPClient:    &http.Client{Transport: auth},
-		authenticator: auth,
+type headerSetter struct {
+	headers map[string]string
+	next    http.RoundTripper
+}
+
+func (h *headerSetter) RoundTrip(request *http.Request) (*http.Response, error) {
+	for key, value := range h.headers {
+		request.Header.Set(key, value)
 	}
+	return h.next.RoundTrip(request)
 }
 
-func call[T any](ctx context.Context, c *Client, endpoint string) (T, error) {
-	target := c.URL + endpoint
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
-	req.Header.Add("Accept", "application/json")
+var _ http.RoundTripper = &rateLimiter{}
 
-	var response struct {
-		MediaContainer T `json:"MediaContainer"`
+type rateLimiter struct {
+	limiter *rate.Limiter
+	next    http.RoundTripper
+}
+
+func (r *rateLimiter) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := r.limiter.Wait(request.Context()); err != nil {
+		return nil, err
 	}
+	return r.next.RoundTrip(request)
+}
+
+// CheckAuth verifies that the client can authenticate with the Plex Media Server. It resolves the token (logging into
+// plex.tv if needed) and performs a lightweight authenticated request. If either step is rejected, CheckAuth returns
+// an error wrapping ErrUnauthorized.
+//
+// Since authentication otherwise happens on the first API call, CheckAuth allows an application to fail fast on
+// invalid credentials at startup. Likewise, if the Client's URL is malformed, CheckAuth returns an error wrapping ErrInvalidURL.
+func (c *Client) CheckAuth(ctx context.Context) error {
+	if err := validateURL(c.URL); err != nil {
+		return err
+	}
+	if _, err := c.GetAuthToken(ctx); err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	// Note: /identity doesn't require a token, so we probe the (lightweight) root endpoint instead
+	req, err := c.newRequest(ctx, http.MethodGet, "/")
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return response.MediaContainer, err
+		return err
+	}
+	_ = resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+	default:
+		return errors.New(resp.Status)
+	}
+}
+
+func validateURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%w: %q has no scheme or host", ErrInvalidURL, target)
+	}
+	return nil
+}
+
+// Close stops all background goroutines started by the Client (e.g. by WatchNotifications) and waits for them to exit.
+// The channels returned by those methods are closed. Afterwards, those methods return ErrClientClosed.
+// Requests that don't run in the background are not affected.
+func (c *Client) Close() error {
+	c.watchLock.Lock()
+	c.closed = true
+	if c.stop != nil {
+		c.stop()
+	}
+	c.watchLock.Unlock()
+	c.watchers.Wait()
+	return nil
+}
+
+// goWatch runs f in a background goroutine, which Close stops and waits for. The context passed to f is cancelled
+// when ctx is done, or when the Client is closed.
+func (c *Client) goWatch(ctx context.Context, f func(ctx context.Context)) error {
+	c.watchLock.Lock()
+	defer c.watchLock.Unlock()
+	if c.closed {
+		return ErrClientClosed
+	}
+	if c.lifetime == nil {
+		c.lifetime, c.stop = context.WithCancel(context.Background())
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.lifetime, cancel)
+	c.watchers.Add(1)
+	go func() {
+		defer c.watchers.Done()
+		defer stop()
+		defer cancel()
+		f(ctx)
+	}()
+	return nil
+}
+
+func call[T any](ctx context.Context, c *Client, endpoint string) (T, error) {
+	return callWithHeaders[T](ctx, c, endpoint, nil)
+}
+
+// callPaged retrieves all items of a (paginated) endpoint, requesting c.containerSize items at a time.
+func callPaged[T any](ctx context.Context, c *Client, endpoint string) ([]T, error) {
+	items, _, err := callPagedContainer[T](ctx, c, endpoint)
+	return items, err
+}
+
+// mediaContainer contains the attributes of a MediaContainer that describe its content.
+type mediaContainer struct {
+	Size             int             `json:"size"`
+	Offset           int             `json:"offset"`
+	TotalSize        int             `json:"totalSize"`
+	LibrarySectionID json.RawMessage `json:"librarySectionID"`
+}
+
+// callPagedContainer works like callPaged, but also returns the attributes of the (last) MediaContainer.
+func callPagedContainer[T any](ctx context.Context, c *Client, endpoint string) ([]T, mediaContainer, error) {
+	type response struct {
+		mediaContainer
+		Metadata []T `json:"Metadata"`
+	}
+	var items []T
+	for {
+		// don't start another page once the context is done (e.g. if the previous page was served from the cache)
+		if err := ctx.Err(); err != nil {
+			return nil, mediaContainer{}, err
+		}
+		start := len(items)
+		headers := make(http.Header)
+		headers.Set("X-Plex-Container-Start", strconv.Itoa(start))
+		headers.Set("X-Plex-Container-Size", strconv.Itoa(c.containerSize))
+		resp, err := callWithHeaders[response](ctx, c, endpoint, headers)
+		if err != nil {
+			return nil, resp.mediaContainer, err
+		}
+		// a page larger than requested means the server ignored the paging headers and returned the full list
+		if len(resp.Metadata) > c.containerSize {
+			return resp.Metadata, resp.mediaContainer, nil
+		}
+		// a page at a different offset means the server ignored the paging headers: the page repeats earlier items
+		if resp.Offset != start {
+			return items, resp.mediaContainer, nil
+		}
+		items = append(items, resp.Metadata...)
+		// stop on a partial (or empty) page, or once we have all items. Without a total size, we can't tell
+		// if the server pages, so asking for the next page could return the same items forever.
+		if len(resp.Metadata) < c.containerSize || resp.TotalSize == 0 || len(items) >= resp.TotalSize {
+			return items, resp.mediaContainer, nil
+		}
+	}
+}
+
+func callWithHeaders[T any](ctx context.Context, c *Client, endpoint string, headers http.Header) (T, error) {
+	if c.cache != nil && cacheable(endpoint) {
+		body, err := c.getCached(ctx, endpoint, headers)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return decode[T](bytes.NewReader(body), c.strictDecoding)
+	}
+
+	resp, err := c.get(ctx, endpoint, headers)
+	if err != nil {
+		var zero T
+		return zero, err
 	}
 
 	defer func() {
@@ -55,12 +382,110 @@ func call[T any](ctx context.Context, c *Client, endpoint string) (T, error) {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return response.MediaContainer, errors.New(resp.Status)
+		var zero T
+		return zero, errors.New(resp.Status)
 	}
 
-	if err = json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		err = fmt.Errorf("decode: %w", err)
+	body, err := c.responseBody(resp)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return decode[T](body, c.strictDecoding)
+}
+
+// newRequest creates a request for the specified endpoint of the server. It returns an error if the resulting URL
+// is invalid (e.g. if the Client's URL is malformed).
+func (c *Client) newRequest(ctx context.Context, method string, endpoint string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
 	}
+	return req, nil
+}
 
+// send sends a request that doesn't return any data (e.g. an update or action), using the specified HTTP method.
+func (c *Client) send(ctx context.Context, method string, endpoint string) error {
+	req, err := c.newRequest(ctx, method, endpoint)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return errors.New(resp.Status)
+	}
+	// the request changed the server's state: any cached responses may now be stale.
+	c.invalidateCache()
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, headers http.Header) (*http.Response, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+	// request compression explicitly, rather than relying on the transport: responseBody decompresses the response.
+	req.Header.Add("Accept-Encoding", "gzip")
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// responseBody returns the response's (decompressed) body, limited to the Client's maximum response size.
+func (c *Client) responseBody(resp *http.Response) (io.Reader, error) {
+	var body io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		r, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("gzip: %w", err)
+		}
+		body = r
+	}
+	if c.maxResponseSize > 0 {
+		body = &limitedReader{reader: body, remaining: c.maxResponseSize}
+	}
+	return body, nil
+}
+
+// limitedReader reads from reader until remaining bytes have been read. Unlike io.LimitedReader, it returns
+// ErrResponseTooLarge if the reader has more data.
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	// read one extra byte to detect if the reader exceeds the limit
+	// (comparing against len(p)-1 avoids overflowing remaining+1 for very large limits)
+	if l.remaining < int64(len(p))-1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.reader.Read(p)
+	if l.remaining -= int64(n); l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func decode[T any](body io.Reader, strict bool) (T, error) {
+	var response struct {
+		MediaContainer T `json:"MediaContainer"`
+	}
+	dec := json.NewDecoder(body)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(&response)
+	if err != nil {
+		err = fmt.Errorf("decode: %w", err)
+	}
 	return response.MediaContainer, err
 }
@@ -6,9 +6,13 @@ import (
 	"github.com/clambin/mediaclients/plex/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestClient_Failures(t *testing.T) {
@@ -37,6 +41,95 @@ func TestClient_Decode_Failure(t *testing.T) {
 	assert.Equal(t, "decode: invalid character 'h' in literal true (expecting 'r')", err.Error())
 }
 
+func TestWithHeaders(t *testing.T) {
+	s := httptest.NewServer(testutil.WithToken("some_token", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("CF-Access-Client-Id") != "some_id" {
+			http.Error(w, "missing header", http.StatusForbidden)
+			return
+		}
+		testutil.TestServer.ServeHTTP(w, r)
+	})))
+	defer s.Close()
+
+	headers := map[string]string{"CF-Access-Client-Id": "some_id"}
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithHeaders(headers))
+	c.SetAuthToken("some_token")
+
+	// the client uses a copy of the headers
+	headers["CF-Access-Client-Id"] = "other_id"
+
+	identity, err := c.GetIdentity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "SomeVersion", identity.Version)
+}
+
+func TestWithRateLimit(t *testing.T) {
+	s := httptest.NewServer(testutil.WithToken("some_token", &testutil.TestServer))
+	defer s.Close()
+
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithRateLimit(20, 1))
+	c.SetAuthToken("some_token")
+
+	start := time.Now()
+	for range 3 {
+		_, err := c.GetIdentity(context.Background())
+		require.NoError(t, err)
+	}
+	// first request is allowed immediately, the next two are spaced 50ms apart
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := c.GetIdentity(ctx)
+	assert.Error(t, err)
+
+	// invalid values are ignored
+	c = plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithRateLimit(0, 0))
+	c.SetAuthToken("some_token")
+	_, err = c.GetIdentity(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestWithMaxResponseSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int64
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "unlimited",
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "within limit",
+			size:    1024,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "maximum limit",
+			size:    math.MaxInt64,
+			wantErr: assert.NoError,
+		},
+		{
+			name: "too large",
+			size: 10,
+			wantErr: func(t assert.TestingT, err error, _ ...interface{}) bool {
+				return assert.ErrorIs(t, err, plex.ErrResponseTooLarge)
+			},
+		},
+	}
+	s := httptest.NewServer(&testutil.TestServer)
+	defer s.Close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithMaxResponseSize(tt.size))
+			c.HTTPClient.Transport = http.DefaultTransport
+			_, err := c.GetIdentity(context.Background())
+			tt.wantErr(t, err)
+		})
+	}
+}
+
 func makeClientAndServer(h http.Handler) (*plex.Client, *httptest.Server) {
 	if h == nil {
 		h = &testutil.TestServer
@@ -47,3 +140,155 @@ func makeClientAndServer(h http.Handler) (*plex.Client, *httptest.Server) {
 	c.HTTPClient.Transport = http.DefaultTransport
 	return c, s
 }
+
+func TestWithStrictDecoding(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{ "MediaContainer": { "size": 0, "machineIdentifier": "SomeUUID", "version": "SomeVersion", "somethingNew": true } }`))
+	}))
+	defer s.Close()
+
+	// by default, unknown attributes are ignored
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.HTTPClient.Transport = http.DefaultTransport
+	identity, err := c.GetIdentity(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "SomeVersion", identity.Version)
+
+	// in strict mode, they result in an error
+	c = plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithStrictDecoding())
+	c.HTTPClient.Transport = http.DefaultTransport
+	_, err = c.GetIdentity(context.Background())
+	assert.ErrorContains(t, err, `unknown field "somethingNew"`)
+}
+
+func TestClient_Deadline(t *testing.T) {
+	// slowServer serves the first page of movies immediately, but stalls on the next one
+	slowServer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/library/sections/1/all" && r.Header.Get("X-Plex-Container-Start") == "0" {
+			_, _ = w.Write([]byte(`{ "MediaContainer": { "librarySectionID": 1, "totalSize": 2, "Metadata": [ { "title": "foo" } ] } }`))
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	s := httptest.NewServer(slowServer)
+	defer s.Close()
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithContainerSize(1))
+	c.HTTPClient.Transport = http.DefaultTransport
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{
+			name: "call",
+			call: func(ctx context.Context) error {
+				_, err := c.GetIdentity(ctx)
+				return err
+			},
+		},
+		{
+			name: "paged",
+			call: func(ctx context.Context) error {
+				_, err := c.GetMovies(ctx, "1")
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := tt.call(ctx)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
+
+func TestWithRedirectPolicy(t *testing.T) {
+	// targets only accept authenticated requests
+	httpsTarget := httptest.NewTLSServer(testutil.WithToken("some_token", &testutil.TestServer))
+	defer httpsTarget.Close()
+	httpTarget := httptest.NewServer(testutil.WithToken("some_token", &testutil.TestServer))
+	defer httpTarget.Close()
+
+	tests := []struct {
+		name           string
+		target         string
+		allowCrossHost bool
+		wantErr        error
+	}{
+		{name: "same host, https", target: httpsTarget.URL},
+		{name: "cross host allowed", target: strings.Replace(httpTarget.URL, "127.0.0.1", "localhost", 1), allowCrossHost: true},
+		{name: "cross host refused", target: strings.Replace(httpTarget.URL, "127.0.0.1", "localhost", 1), wantErr: plex.ErrCrossHostRedirect},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, tt.target+r.URL.Path, http.StatusMovedPermanently)
+			}))
+			defer s.Close()
+
+			c := plex.New("user@example.com", "somepassword", "", "", s.URL, httpsTarget.Client().Transport, plex.WithRedirectPolicy(5, tt.allowCrossHost))
+			c.SetAuthToken("some_token")
+
+			identity, err := c.GetIdentity(context.Background())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "SomeVersion", identity.Version)
+		})
+	}
+}
+
+func TestWithRedirectPolicy_MaxRedirects(t *testing.T) {
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Redirect(w, r, r.URL.Path, http.StatusFound)
+	}))
+	defer s.Close()
+
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithRedirectPolicy(3, false))
+	c.SetAuthToken("some_token")
+
+	_, err := c.GetIdentity(context.Background())
+	assert.ErrorContains(t, err, "stopped after 3 redirects")
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestClient_InvalidURL(t *testing.T) {
+	for _, target := range []string{"http://plex server:32400", "http://[::1"} {
+		t.Run(target, func(t *testing.T) {
+			c := plex.New("user@example.com", "somepassword", "", "", target, nil)
+			c.SetAuthToken("some_token")
+			ctx := context.Background()
+
+			assert.ErrorIs(t, c.CheckAuth(ctx), plex.ErrInvalidURL)
+			_, err := c.GetIdentity(ctx)
+			assert.ErrorContains(t, err, "invalid request")
+			assert.ErrorContains(t, c.RefreshMetadata(ctx, "100"), "invalid request")
+			_, _, err = c.DownloadPart(ctx, "/library/parts/1/1234/file.mkv", 0)
+			assert.ErrorContains(t, err, "invalid request")
+		})
+	}
+}
+
+func TestClient_CheckAuth_InvalidURL(t *testing.T) {
+	for _, target := range []string{"http://plex server:32400", "http://[::1", "localhost:32400", "/identity", ""} {
+		t.Run(target, func(t *testing.T) {
+			// no token: CheckAuth must reject the URL before logging in to plex.tv
+			c := plex.New("user@example.com", "somepassword", "", "", target, nil)
+			assert.ErrorIs(t, c.CheckAuth(context.Background()), plex.ErrInvalidURL)
+		})
+	}
+}
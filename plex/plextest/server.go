@@ -0,0 +1,190 @@
+// Package plextest provides a fake Plex Media Server, for testing code that uses a plex.Client.
+package plextest
+
+import (
+	"encoding/json"
+	"github.com/clambin/mediaclients/plex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+)
+
+// Token is the token that a Server accepts, unless overridden by WithToken.
+const Token = "plextest-token"
+
+// Server is a fake Plex Media Server. It serves canned responses for the identity, session and library endpoints.
+// Requests that don't carry the server's token (in the X-Plex-Token header or query parameter) are rejected
+// with http.StatusUnauthorized.
+//
+// Use NewClient to create a plex.Client that talks to the Server, without needing to log in to plex.tv.
+type Server struct {
+	*httptest.Server
+	token     string
+	lock      sync.RWMutex
+	responses map[string]response
+}
+
+// response is the response for one path. A list response (listKey is set) is paged according to the
+// X-Plex-Container-Start and X-Plex-Container-Size headers, like the Plex Media Server does.
+type response struct {
+	body       []byte
+	listKey    string
+	items      []json.RawMessage
+	attributes map[string]any
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithToken sets the token the Server accepts. If token is blank, the Server accepts any request.
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// NewServer starts a new Server. Initially, the Server has an identity, but no sessions or libraries.
+// Call Close when done.
+func NewServer(options ...Option) *Server {
+	s := Server{
+		token:     Token,
+		responses: make(map[string]response),
+	}
+	for _, option := range options {
+		option(&s)
+	}
+	s.SetIdentity(plex.Identity{Claimed: true, MachineIdentifier: "plextest", Version: "1.0.0"})
+	s.SetSessions()
+	s.SetLibraries()
+	s.Server = httptest.NewServer(&s)
+	return &s
+}
+
+// NewClient returns a plex.Client for the Server, configured with the Server's token.
+func (s *Server) NewClient(options ...plex.Option) *plex.Client {
+	c := plex.New("", "", "plextest", "1.0.0", s.URL, nil, options...)
+	c.SetAuthToken(s.token)
+	return c
+}
+
+// SetIdentity sets the response to the /identity endpoint (and the root endpoint, used by plex.Client.CheckAuth).
+func (s *Server) SetIdentity(identity plex.Identity) {
+	s.set("/", identity)
+	s.set("/identity", identity)
+}
+
+// SetSessions sets the sessions returned by the /status/sessions endpoint.
+func (s *Server) SetSessions(sessions ...plex.Session) {
+	setList(s, "/status/sessions", "Metadata", sessions, nil)
+}
+
+// SetLibraries sets the libraries returned by the /library/sections endpoint.
+func (s *Server) SetLibraries(libraries ...plex.Library) {
+	setList(s, "/library/sections", "Directory", libraries, nil)
+}
+
+// SetMovies sets the movies in the library with the specified key.
+func (s *Server) SetMovies(key string, movies ...plex.Movie) {
+	setList(s, "/library/sections/"+key+"/all", "Metadata", movies, section(key))
+}
+
+// SetShows sets the shows in the library with the specified key.
+func (s *Server) SetShows(key string, shows ...plex.Show) {
+	setList(s, "/library/sections/"+key+"/all", "Metadata", shows, section(key))
+}
+
+// SetResponse sets the MediaContainer returned for the specified path. This can be used for endpoints
+// that don't have a dedicated setter. The response is not paged.
+func (s *Server) SetResponse(path string, mediaContainer any) {
+	s.set(path, mediaContainer)
+}
+
+func (s *Server) set(path string, mediaContainer any) {
+	body, err := json.Marshal(struct {
+		MediaContainer any `json:"MediaContainer"`
+	}{MediaContainer: mediaContainer})
+	if err != nil {
+		panic("plextest: " + err.Error())
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[path] = response{body: body}
+}
+
+func setList[T any](s *Server, path string, listKey string, items []T, attributes map[string]any) {
+	r := response{listKey: listKey, items: make([]json.RawMessage, len(items)), attributes: attributes}
+	for i, item := range items {
+		var err error
+		if r.items[i], err = json.Marshal(item); err != nil {
+			panic("plextest: " + err.Error())
+		}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.responses[path] = r
+}
+
+// section returns the attributes of a library section's MediaContainer. Plex uses these to indicate the section exists
+// (see plex.WithLibraryNotFound).
+func section(key string) map[string]any {
+	var id any = key
+	if n, err := strconv.Atoi(key); err == nil {
+		id = n
+	}
+	return map[string]any{"librarySectionID": id}
+}
+
+// page returns the response's body, for the requested page of items.
+func (r response) page(req *http.Request) []byte {
+	if r.listKey == "" {
+		return r.body
+	}
+	start := min(max(containerParameter(req, "X-Plex-Container-Start", 0), 0), len(r.items))
+	size := max(containerParameter(req, "X-Plex-Container-Size", len(r.items)), 0)
+	end := min(start+size, len(r.items))
+
+	container := make(map[string]any, len(r.attributes)+3)
+	for key, value := range r.attributes {
+		container[key] = value
+	}
+	container["size"] = end - start
+	container["offset"] = start
+	container["totalSize"] = len(r.items)
+	container[r.listKey] = r.items[start:end]
+	body, _ := json.Marshal(map[string]any{"MediaContainer": container})
+	return body
+}
+
+// containerParameter returns the paging parameter from the request's headers or query (Plex accepts either).
+func containerParameter(req *http.Request, name string, defaultValue int) int {
+	value := req.Header.Get(name)
+	if value == "" {
+		value = req.URL.Query().Get(name)
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return n
+	}
+	return defaultValue
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && r.Header.Get("X-Plex-Token") != s.token && r.URL.Query().Get("X-Plex-Token") != s.token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.lock.RLock()
+	resp, ok := s.responses[r.URL.Path]
+	s.lock.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp.page(r))
+}
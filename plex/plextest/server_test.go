@@ -0,0 +1,125 @@
+package plextest_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/clambin/mediaclients/plex"
+	"github.com/clambin/mediaclients/plex/plextest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServer(t *testing.T) {
+	s := plextest.NewServer()
+	defer s.Close()
+
+	s.SetSessions(
+		plex.Session{SessionKey: "1", Title: "pilot", Type: "episode", User: plex.SessionUser{Title: "foo"}},
+		plex.Session{SessionKey: "2", Title: "movie", Type: "movie", User: plex.SessionUser{Title: "bar"}},
+	)
+	s.SetLibraries(plex.Library{Key: "1", Type: "movie", Title: "Movies"})
+	s.SetMovies("1", plex.Movie{RatingKey: "100", Title: "foo"})
+
+	c := s.NewClient()
+	ctx := context.Background()
+
+	require.NoError(t, c.CheckAuth(ctx))
+
+	identity, err := c.GetIdentity(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "plextest", identity.MachineIdentifier)
+
+	sessions, err := c.GetSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "pilot", sessions[0].Title)
+	assert.Equal(t, "bar", sessions[1].User.Title)
+
+	libraries, err := c.GetLibraries(ctx)
+	require.NoError(t, err)
+	require.Len(t, libraries, 1)
+	assert.Equal(t, "Movies", libraries[0].Title)
+
+	movies, err := c.GetMovies(ctx, "1")
+	require.NoError(t, err)
+	require.Len(t, movies, 1)
+	assert.Equal(t, "foo", movies[0].Title)
+
+	_, err = c.GetMovies(ctx, "2")
+	assert.Error(t, err)
+}
+
+func TestServer_Token(t *testing.T) {
+	s := plextest.NewServer(plextest.WithToken("secret"))
+	defer s.Close()
+
+	c := plex.New("", "", "", "", s.URL, nil)
+	c.SetAuthToken("wrong")
+	_, err := c.GetIdentity(context.Background())
+	assert.EqualError(t, err, "401 Unauthorized")
+
+	resp, err := http.Get(s.URL + "/identity?X-Plex-Token=secret")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	c.SetAuthToken("secret")
+	_, err = c.GetIdentity(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestServer_EmptyLibrary(t *testing.T) {
+	s := plextest.NewServer()
+	defer s.Close()
+	s.SetMovies("1")
+
+	for _, c := range []*plex.Client{s.NewClient(), s.NewClient(plex.WithLibraryNotFound())} {
+		movies, err := c.GetMovies(context.Background(), "1")
+		require.NoError(t, err)
+		assert.Empty(t, movies)
+	}
+
+	_, err := s.NewClient(plex.WithLibraryNotFound()).GetMovies(context.Background(), "2")
+	assert.Error(t, err)
+}
+
+func TestServer_Paging(t *testing.T) {
+	s := plextest.NewServer()
+	defer s.Close()
+	s.SetMovies("1",
+		plex.Movie{RatingKey: "1", Title: "foo", AddedAt: plex.Timestamp(time.Unix(1655899131, 0).UTC())},
+		plex.Movie{RatingKey: "2", Title: "bar"},
+		plex.Movie{RatingKey: "3", Title: "snafu"},
+	)
+
+	movies, err := s.NewClient(plex.WithContainerSize(2)).GetMovies(context.Background(), "1")
+	require.NoError(t, err)
+	require.Len(t, movies, 3)
+	assert.Equal(t, "snafu", movies[2].Title)
+	assert.Equal(t, int64(1655899131), time.Time(movies[0].AddedAt).Unix())
+
+	req, _ := http.NewRequest(http.MethodGet, s.URL+"/library/sections/1/all", nil)
+	req.Header.Set("X-Plex-Token", plextest.Token)
+	req.Header.Set("X-Plex-Container-Start", "1")
+	req.Header.Set("X-Plex-Container-Size", "1")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	var body struct {
+		MediaContainer struct {
+			Size             int          `json:"size"`
+			TotalSize        int          `json:"totalSize"`
+			LibrarySectionID int          `json:"librarySectionID"`
+			Metadata         []plex.Movie `json:"Metadata"`
+		}
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, 1, body.MediaContainer.Size)
+	assert.Equal(t, 3, body.MediaContainer.TotalSize)
+	assert.Equal(t, 1, body.MediaContainer.LibrarySectionID)
+	require.Len(t, body.MediaContainer.Metadata, 1)
+	assert.Equal(t, "bar", body.MediaContainer.Metadata[0].Title)
+}
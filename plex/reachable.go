@@ -0,0 +1,60 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// FindReachable probes the /identity endpoint of each URI concurrently and returns the first URI that responds
+// within the timeout. This can be used to select a working connection for a server that has multiple addresses
+// (e.g. local, remote, relay).
+func (c *Client) FindReachable(ctx context.Context, uris []string, timeout time.Duration) (string, error) {
+	if len(uris) == 0 {
+		return "", errors.New("no uris to probe")
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		uri string
+		err error
+	}
+	results := make(chan result, len(uris))
+	for _, uri := range uris {
+		go func(uri string) {
+			results <- result{uri: uri, err: c.probe(ctx, uri)}
+		}(uri)
+	}
+
+	errs := make([]error, 0, len(uris))
+	for range uris {
+		r := <-results
+		if r.err == nil {
+			return r.uri, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", r.uri, r.err))
+	}
+	return "", fmt.Errorf("no reachable uri: %w", errors.Join(errs...))
+}
+
+func (c *Client) probe(ctx context.Context, uri string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri+"/identity", nil)
+	if err != nil {
+		return err
+	}
+	// /identity doesn't require a token: probe without the authenticator, so we don't send the token to hosts
+	// that may not be the server (e.g. a relay), or depend on plex.tv being available.
+	probeClient := http.Client{Transport: c.authenticator.next, CheckRedirect: c.checkRedirect}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package plex_test
+
+import (
+	"context"
+	"github.com/clambin/mediaclients/plex"
+	"github.com/clambin/mediaclients/plex/internal/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_FindReachable(t *testing.T) {
+	reachable := httptest.NewServer(&testutil.TestServer)
+	defer reachable.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	c, s := makeClientAndServer(nil)
+	defer s.Close()
+
+	uri, err := c.FindReachable(context.Background(), []string{unreachable.URL, slow.URL, reachable.URL}, 500*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, reachable.URL, uri)
+
+	_, err = c.FindReachable(context.Background(), []string{unreachable.URL, slow.URL}, 100*time.Millisecond)
+	assert.Error(t, err)
+
+	_, err = c.FindReachable(context.Background(), nil, 100*time.Millisecond)
+	assert.Error(t, err)
+}
+
+func TestClient_FindReachable_NoToken(t *testing.T) {
+	var token string
+	var called bool
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		token = r.Header.Get("X-Plex-Token")
+		testutil.TestServer.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+
+	c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil)
+	c.SetAuthToken("some_token")
+
+	uri, err := c.FindReachable(context.Background(), []string{s.URL}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, s.URL, uri)
+	assert.True(t, called)
+	assert.Empty(t, token)
+}
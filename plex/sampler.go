@@ -0,0 +1,72 @@
+package plex
+
+import (
+	"sync"
+	"time"
+)
+
+// SessionSampler turns successive GetSessions results into per-session rates of change (e.g. to graph trends).
+// It remembers the previous sample of each session, keyed by SessionKey. Sessions that are no longer active are forgotten.
+//
+// The zero value is ready to use. A SessionSampler is safe for concurrent use.
+type SessionSampler struct {
+	lock     sync.Mutex
+	previous map[string]sessionSample
+}
+
+type sessionSample struct {
+	timestamp  time.Time
+	bandwidth  int
+	viewOffset int
+}
+
+// SessionDelta describes how a session changed between two samples.
+type SessionDelta struct {
+	SessionKey string
+	// Interval is the time between the two samples
+	Interval time.Duration
+	// Bandwidth is the session's current bandwidth (see SessionStats.Bandwidth)
+	Bandwidth int
+	// BandwidthDelta is the change in bandwidth since the previous sample
+	BandwidthDelta int
+	// Progress is how far playback advanced since the previous sample. Negative if the user skipped back.
+	Progress time.Duration
+}
+
+// PlaybackRate returns the playback progress per second of wall time: 1.0 for a session that plays normally,
+// 0 for a paused session.
+func (d SessionDelta) PlaybackRate() float64 {
+	if d.Interval <= 0 {
+		return 0
+	}
+	return d.Progress.Seconds() / d.Interval.Seconds()
+}
+
+// Sample records the sessions, as retrieved at timestamp, and returns the delta of each session that was also present
+// in the previous sample. New sessions have no delta until the next sample.
+func (s *SessionSampler) Sample(sessions Sessions, timestamp time.Time) []SessionDelta {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	current := make(map[string]sessionSample, len(sessions))
+	var deltas []SessionDelta
+	for _, session := range sessions {
+		sample := sessionSample{
+			timestamp:  timestamp,
+			bandwidth:  session.Session.Bandwidth,
+			viewOffset: session.ViewOffset,
+		}
+		current[session.SessionKey] = sample
+		if previous, ok := s.previous[session.SessionKey]; ok {
+			deltas = append(deltas, SessionDelta{
+				SessionKey:     session.SessionKey,
+				Interval:       sample.timestamp.Sub(previous.timestamp),
+				Bandwidth:      sample.bandwidth,
+				BandwidthDelta: sample.bandwidth - previous.bandwidth,
+				Progress:       time.Duration(sample.viewOffset-previous.viewOffset) * time.Millisecond,
+			})
+		}
+	}
+	s.previous = current
+	return deltas
+}
@@ -0,0 +1,49 @@
+package plex_test
+
+import (
+	"github.com/clambin/mediaclients/plex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSessionSampler(t *testing.T) {
+	var s plex.SessionSampler
+	start := time.Date(2024, time.January, 1, 20, 0, 0, 0, time.UTC)
+
+	deltas := s.Sample(plex.Sessions{
+		{SessionKey: "1", ViewOffset: 60_000, Session: plex.SessionStats{Bandwidth: 4000}},
+		{SessionKey: "2", ViewOffset: 10_000, Session: plex.SessionStats{Bandwidth: 8000}},
+	}, start)
+	assert.Empty(t, deltas)
+
+	deltas = s.Sample(plex.Sessions{
+		// playing
+		{SessionKey: "1", ViewOffset: 70_000, Session: plex.SessionStats{Bandwidth: 5000}},
+		// paused
+		{SessionKey: "2", ViewOffset: 10_000, Session: plex.SessionStats{Bandwidth: 1000}},
+		// new session
+		{SessionKey: "3", ViewOffset: 0, Session: plex.SessionStats{Bandwidth: 2000}},
+	}, start.Add(10*time.Second))
+
+	assert.Equal(t, []plex.SessionDelta{
+		{SessionKey: "1", Interval: 10 * time.Second, Bandwidth: 5000, BandwidthDelta: 1000, Progress: 10 * time.Second},
+		{SessionKey: "2", Interval: 10 * time.Second, Bandwidth: 1000, BandwidthDelta: -7000, Progress: 0},
+	}, deltas)
+	assert.Equal(t, 1.0, deltas[0].PlaybackRate())
+	assert.Equal(t, 0.0, deltas[1].PlaybackRate())
+
+	// sessions that ended are forgotten
+	deltas = s.Sample(plex.Sessions{
+		{SessionKey: "3", ViewOffset: 5_000, Session: plex.SessionStats{Bandwidth: 2000}},
+	}, start.Add(20*time.Second))
+	assert.Equal(t, []plex.SessionDelta{
+		{SessionKey: "3", Interval: 10 * time.Second, Bandwidth: 2000, Progress: 5 * time.Second},
+	}, deltas)
+	assert.Equal(t, 0.5, deltas[0].PlaybackRate())
+
+	deltas = s.Sample(plex.Sessions{
+		{SessionKey: "1", ViewOffset: 80_000, Session: plex.SessionStats{Bandwidth: 5000}},
+	}, start.Add(30*time.Second))
+	assert.Empty(t, deltas)
+}
@@ -2,8 +2,11 @@ package plex
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/clambin/go-common/set"
+	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -20,6 +23,117 @@ func (c *Client) GetSessions(ctx context.Context) ([]Session, error) {
 	return resp.Metadata, err
 }
 
+// ErrSessionNotFound indicates that the requested session does not exist.
+var ErrSessionNotFound = errors.New("session not found")
+
+// GetSessionByKey returns the session with the specified SessionKey. If the session does not exist, it returns ErrSessionNotFound.
+//
+// Note: Plex doesn't support retrieving a single session, so this still retrieves all sessions.
+func (c *Client) GetSessionByKey(ctx context.Context, sessionKey string) (Session, error) {
+	sessions, err := c.GetSessions(ctx)
+	if err != nil {
+		return Session{}, err
+	}
+	for _, session := range sessions {
+		if session.SessionKey == sessionKey {
+			return session, nil
+		}
+	}
+	return Session{}, ErrSessionNotFound
+}
+
+// TerminateSession stops the session with the specified ID (i.e. Session.Session.ID, not its SessionKey).
+// reason is the message shown to the user. Terminating sessions requires a Plex Pass.
+func (c *Client) TerminateSession(ctx context.Context, sessionID string, reason string) error {
+	query := url.Values{
+		"sessionId": []string{sessionID},
+		"reason":    []string{reason},
+	}
+	if err := c.send(ctx, http.MethodGet, "/status/sessions/terminate?"+query.Encode()); err != nil {
+		return fmt.Errorf("terminate: %w", err)
+	}
+	return nil
+}
+
+// TerminateSessions stops each session with the specified ID (see TerminateSession). If a session can't be terminated,
+// TerminateSessions continues with the next one. It returns an error for each session that failed, or nil if all
+// sessions were terminated.
+func (c *Client) TerminateSessions(ctx context.Context, reason string, sessionIDs ...string) []error {
+	var errs []error
+	for _, sessionID := range sessionIDs {
+		if err := c.TerminateSession(ctx, sessionID, reason); err != nil {
+			errs = append(errs, fmt.Errorf("session %s: %w", sessionID, err))
+		}
+	}
+	return errs
+}
+
+// GetSessionsConditional retrieves session information from the server, if it changed since the previous call.
+// etag is the ETag returned by the previous call (or blank for the first call). If the sessions did not change,
+// GetSessionsConditional returns changed == false and no sessions.
+//
+// Note: this only saves bandwidth if the server supports conditional requests. Otherwise, GetSessionsConditional
+// always returns the full list of sessions and changed == true.
+func (c *Client) GetSessionsConditional(ctx context.Context, etag string) (sessions []Session, newETag string, changed bool, err error) {
+	headers := make(http.Header)
+	if etag != "" {
+		headers.Set("If-None-Match", etag)
+	}
+	resp, err := c.get(ctx, "/status/sessions", headers)
+	if err != nil {
+		return nil, etag, false, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, false, nil
+	case http.StatusOK:
+		type response struct {
+			Metadata []Session `json:"Metadata"`
+		}
+		body, err := c.responseBody(resp)
+		if err != nil {
+			return nil, etag, false, err
+		}
+		r, err := decode[response](body, c.strictDecoding)
+		return r.Metadata, resp.Header.Get("ETag"), err == nil, err
+	default:
+		return nil, etag, false, errors.New(resp.Status)
+	}
+}
+
+// Sessions contains all sessions returned by GetSessions, e.g. Sessions(sessions).TranscodeLoad().
+type Sessions []Session
+
+// TranscodeLoad contains the transcoder load of one session
+type TranscodeLoad struct {
+	User      string
+	Speed     float64
+	Throttled bool
+	HwAccel   bool
+}
+
+// TranscodeLoad returns the transcoder load of each session that is transcoding.  Sessions that play the media
+// directly (i.e. without a transcoder) are skipped.
+func (s Sessions) TranscodeLoad() []TranscodeLoad {
+	load := make([]TranscodeLoad, 0, len(s))
+	for _, session := range s {
+		if session.TranscodeSession == (SessionTranscoder{}) {
+			continue
+		}
+		load = append(load, TranscodeLoad{
+			User:      session.User.Title,
+			Speed:     session.TranscodeSession.Speed,
+			Throttled: session.TranscodeSession.Throttled,
+			HwAccel:   session.TranscodeSession.TranscodeHwFullPipeline,
+		})
+	}
+	return load
+}
+
 // Session contains one record in a Sessions
 type Session struct {
 	AddedAt               int            `json:"addedAt"`
@@ -69,12 +183,8 @@ type Session struct {
 		ID     string `json:"id"`
 		Tag    string `json:"tag"`
 	} `json:"Writer"`
-	Rating2 []struct {
-		Image string `json:"image"`
-		Type  string `json:"type"`
-		Value string `json:"value"`
-	} `json:"Rating"`
-	Role []struct {
+	Ratings []Rating `json:"Rating"`
+	Role    []struct {
 		Filter string `json:"filter"`
 		ID     string `json:"id"`
 		Role   string `json:"role"`
@@ -87,6 +197,24 @@ type Session struct {
 	TranscodeSession SessionTranscoder `json:"TranscodeSession"`
 }
 
+// Rating contains one rating (e.g. audience, critic) of the media played by a Session
+type Rating struct {
+	Image string `json:"image"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// RatingByType returns the value of the session's rating of the specified type (e.g. "audience", "critic").
+// If the session has no rating of that type, it returns false.
+func (s Session) RatingByType(t string) (string, bool) {
+	for _, rating := range s.Ratings {
+		if rating.Type == t {
+			return rating.Value, true
+		}
+	}
+	return "", false
+}
+
 // SessionMedia contains one record in a Session's Media list
 type SessionMedia struct {
 	AudioProfile          string             `json:"audioProfile"`
@@ -108,6 +236,24 @@ type SessionMedia struct {
 	Part                  []MediaSessionPart `json:"Part"`
 }
 
+// ResolutionLabel returns the media's video resolution as a label, e.g. "2160p" (for "4k"), "1080p" or "SD".
+func (m SessionMedia) ResolutionLabel() string {
+	return resolutionLabel(m.VideoResolution)
+}
+
+// IsHDR returns true if the media's video stream uses a high dynamic range format (e.g. HDR10, HLG or Dolby Vision).
+// This is determined from the video stream's color data, if the server reports it, or its display title otherwise.
+func (m SessionMedia) IsHDR() bool {
+	for _, part := range m.Part {
+		for _, stream := range part.Stream {
+			if stream.StreamType == StreamTypeVideo && stream.IsHDR() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // MediaSessionPart contains one record in a MediaSession's Part list
 type MediaSessionPart struct {
 	AudioProfile          string                   `json:"audioProfile"`
@@ -120,39 +266,91 @@ type MediaSessionPart struct {
 	OptimizedForStreaming bool                     `json:"optimizedForStreaming"`
 	Protocol              string                   `json:"protocol"`
 	Width                 int                      `json:"width"`
-	Decision              string                   `json:"decision"`
+	Decision              Decision                 `json:"decision"`
 	Selected              bool                     `json:"selected"`
 	Stream                []MediaSessionPartStream `json:"Stream"`
 }
 
 // MediaSessionPartStream contains one stream (video, audio, subtitles) in a MediaSession's Part list
 type MediaSessionPartStream struct {
-	Bitrate              int     `json:"bitrate,omitempty"`
-	Codec                string  `json:"codec"`
-	Default              bool    `json:"default"`
-	DisplayTitle         string  `json:"displayTitle"`
-	ExtendedDisplayTitle string  `json:"extendedDisplayTitle"`
-	FrameRate            float64 `json:"frameRate,omitempty"`
-	Height               int     `json:"height,omitempty"`
-	ID                   string  `json:"id"`
-	Language             string  `json:"language"`
-	LanguageCode         string  `json:"languageCode"`
-	LanguageTag          string  `json:"languageTag"`
-	StreamType           int     `json:"streamType"`
-	Width                int     `json:"width,omitempty"`
-	Decision             string  `json:"decision"`
-	Location             string  `json:"location"`
-	AudioChannelLayout   string  `json:"audioChannelLayout,omitempty"`
-	BitrateMode          string  `json:"bitrateMode,omitempty"`
-	Channels             int     `json:"channels,omitempty"`
-	Profile              string  `json:"profile,omitempty"`
-	SamplingRate         int     `json:"samplingRate,omitempty"`
-	Selected             bool    `json:"selected,omitempty"`
-	Title                string  `json:"title,omitempty"`
-	Container            string  `json:"container,omitempty"`
-	Format               string  `json:"format,omitempty"`
+	Bitrate              int      `json:"bitrate,omitempty"`
+	Codec                string   `json:"codec"`
+	Default              bool     `json:"default"`
+	DisplayTitle         string   `json:"displayTitle"`
+	ExtendedDisplayTitle string   `json:"extendedDisplayTitle"`
+	FrameRate            float64  `json:"frameRate,omitempty"`
+	Height               int      `json:"height,omitempty"`
+	ID                   string   `json:"id"`
+	Language             string   `json:"language"`
+	LanguageCode         string   `json:"languageCode"`
+	LanguageTag          string   `json:"languageTag"`
+	StreamType           int      `json:"streamType"`
+	Width                int      `json:"width,omitempty"`
+	Decision             Decision `json:"decision"`
+	Location             string   `json:"location"`
+	AudioChannelLayout   string   `json:"audioChannelLayout,omitempty"`
+	BitrateMode          string   `json:"bitrateMode,omitempty"`
+	Channels             int      `json:"channels,omitempty"`
+	Profile              string   `json:"profile,omitempty"`
+	SamplingRate         int      `json:"samplingRate,omitempty"`
+	Selected             bool     `json:"selected,omitempty"`
+	Title                string   `json:"title,omitempty"`
+	Container            string   `json:"container,omitempty"`
+	Format               string   `json:"format,omitempty"`
+	ColorTrc             string   `json:"colorTrc,omitempty"`
+	DOVIPresent          bool     `json:"DOVIPresent,omitempty"`
+}
+
+// Stream types of a MediaSessionPartStream
+const (
+	StreamTypeVideo    = 1
+	StreamTypeAudio    = 2
+	StreamTypeSubtitle = 3
+)
+
+// IsSubtitle returns true if the stream is a subtitle stream.
+func (s MediaSessionPartStream) IsSubtitle() bool {
+	return s.StreamType == StreamTypeSubtitle
+}
+
+// IsHDR returns true if the (video) stream uses a high dynamic range format (e.g. HDR10, HLG or Dolby Vision).
+func (s MediaSessionPartStream) IsHDR() bool {
+	switch {
+	case s.DOVIPresent:
+		return true
+	case s.ColorTrc != "":
+		// PQ (HDR10, HDR10+, Dolby Vision) or HLG transfer characteristics
+		return s.ColorTrc == "smpte2084" || s.ColorTrc == "arib-std-b67"
+	default:
+		title := strings.ToLower(s.ExtendedDisplayTitle + " " + s.DisplayTitle)
+		return strings.Contains(title, "hdr") || strings.Contains(title, "dolby vision") || strings.Contains(title, "hlg")
+	}
+}
+
+// IsExternal returns true if the stream is stored outside the media file (e.g. a sidecar subtitle file).
+func (s MediaSessionPartStream) IsExternal() bool {
+	return s.Location == "external"
 }
 
+// Decision is the decision Plex made on how to play a media part or stream
+type Decision string
+
+const (
+	DecisionTranscode  Decision = "transcode"
+	DecisionCopy       Decision = "copy"
+	DecisionDirectPlay Decision = "directplay"
+)
+
+// TranscodeContext indicates why a session is transcoding
+type TranscodeContext string
+
+const (
+	// TranscodeContextStreaming is a transcode for a client that is playing the media
+	TranscodeContextStreaming TranscodeContext = "streaming"
+	// TranscodeContextStatic is a transcode that is not tied to playback, e.g. for a sync or optimize job
+	TranscodeContextStatic TranscodeContext = "static"
+)
+
 // SessionUser contains the user details inside a Session
 type SessionUser struct {
 	ID    string `json:"id"`
@@ -190,28 +388,28 @@ type SessionStats struct {
 // SessionTranscoder contains the transcoder details inside a Session.
 // If the session doesn't transcode any media streams, all fields will be blank.
 type SessionTranscoder struct {
-	Key                     string  `json:"key"`
-	Throttled               bool    `json:"throttled"`
-	Complete                bool    `json:"complete"`
-	Progress                float64 `json:"progress"`
-	Size                    int     `json:"size"`
-	Speed                   float64 `json:"speed"`
-	Error                   bool    `json:"error"`
-	Duration                int     `json:"duration"`
-	Context                 string  `json:"context"`
-	SourceVideoCodec        string  `json:"sourceVideoCodec"`
-	SourceAudioCodec        string  `json:"sourceAudioCodec"`
-	VideoDecision           string  `json:"videoDecision"`
-	AudioDecision           string  `json:"audioDecision"`
-	SubtitleDecision        string  `json:"subtitleDecision"`
-	Protocol                string  `json:"protocol"`
-	Container               string  `json:"container"`
-	VideoCodec              string  `json:"videoCodec"`
-	AudioCodec              string  `json:"audioCodec"`
-	AudioChannels           int     `json:"audioChannels"`
-	TranscodeHwRequested    bool    `json:"transcodeHwRequested"`
-	TranscodeHwFullPipeline bool    `json:"transcodeHwFullPipeline"`
-	TimeStamp               float64 `json:"timeStamp"`
+	Key                     string           `json:"key"`
+	Throttled               bool             `json:"throttled"`
+	Complete                bool             `json:"complete"`
+	Progress                float64          `json:"progress"`
+	Size                    int              `json:"size"`
+	Speed                   float64          `json:"speed"`
+	Error                   bool             `json:"error"`
+	Duration                int              `json:"duration"`
+	Context                 TranscodeContext `json:"context"`
+	SourceVideoCodec        string           `json:"sourceVideoCodec"`
+	SourceAudioCodec        string           `json:"sourceAudioCodec"`
+	VideoDecision           Decision         `json:"videoDecision"`
+	AudioDecision           Decision         `json:"audioDecision"`
+	SubtitleDecision        Decision         `json:"subtitleDecision"`
+	Protocol                string           `json:"protocol"`
+	Container               string           `json:"container"`
+	VideoCodec              string           `json:"videoCodec"`
+	AudioCodec              string           `json:"audioCodec"`
+	AudioChannels           int              `json:"audioChannels"`
+	TranscodeHwRequested    bool             `json:"transcodeHwRequested"`
+	TranscodeHwFullPipeline bool             `json:"transcodeHwFullPipeline"`
+	TimeStamp               float64          `json:"timeStamp"`
 }
 
 // GetTitle returns the title of the movie, tv episode being played.  For movies, this is just the title.
@@ -236,13 +434,13 @@ func (s Session) GetVideoMode() string {
 	for _, media := range s.Media {
 		for _, part := range media.Part {
 			videoDecision := part.Decision
-			if videoDecision == "transcode" {
+			if videoDecision == DecisionTranscode {
 				videoDecision = s.TranscodeSession.VideoDecision
 			}
 			if videoDecision == "" {
 				videoDecision = "unknown"
 			}
-			decisions.Add(videoDecision)
+			decisions.Add(string(videoDecision))
 		}
 	}
 	modes := decisions.ListOrdered()
@@ -251,3 +449,19 @@ func (s Session) GetVideoMode() string {
 	}
 	return strings.Join(modes, ",")
 }
+
+// HardwareTranscode reports whether the session requested hardware transcoding and whether the full transcoding
+// pipeline (decoding & encoding) runs in hardware.
+func (s Session) HardwareTranscode() (requested, fullPipeline bool) {
+	return s.TranscodeSession.TranscodeHwRequested, s.TranscodeSession.TranscodeHwFullPipeline
+}
+
+// IsHardwareTranscoding returns true if the session is transcoding fully in hardware.
+func (s Session) IsHardwareTranscoding() bool {
+	return s.TranscodeSession.TranscodeHwFullPipeline
+}
+
+// TranscodeContext returns the transcoding context of the session. If the session isn't transcoding, it returns blank.
+func (s Session) TranscodeContext() TranscodeContext {
+	return s.TranscodeSession.Context
+}
@@ -1,10 +1,14 @@
 package plex_test
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"github.com/clambin/mediaclients/plex"
+	"github.com/clambin/mediaclients/plex/internal/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/http"
 	"testing"
 )
 
@@ -32,6 +36,57 @@ func TestPlexClient_GetStats(t *testing.T) {
 	}
 }
 
+func TestClient_GetSessionByKey(t *testing.T) {
+	c, s := makeClientAndServer(nil)
+	defer s.Close()
+
+	session, err := c.GetSessionByKey(context.Background(), "3")
+	require.NoError(t, err)
+	assert.Equal(t, "movie 2", session.Title)
+
+	_, err = c.GetSessionByKey(context.Background(), "99")
+	assert.ErrorIs(t, err, plex.ErrSessionNotFound)
+}
+
+func TestClient_GetSessionsConditional(t *testing.T) {
+	const etag = `"v1"`
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		testutil.TestServer.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+
+	sessions, newETag, changed, err := c.GetSessionsConditional(context.Background(), "")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, etag, newETag)
+	assert.Len(t, sessions, 4)
+
+	sessions, newETag, changed, err = c.GetSessionsConditional(context.Background(), newETag)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, etag, newETag)
+	assert.Empty(t, sessions)
+}
+
+func TestSessions_TranscodeLoad(t *testing.T) {
+	c, s := makeClientAndServer(nil)
+	defer s.Close()
+
+	sessions, err := c.GetSessions(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []plex.TranscodeLoad{
+		{User: "bar"},
+		{User: "snafu", Speed: 3.1, Throttled: true},
+		{User: "snafu", Speed: 4.1, Throttled: true},
+	}, plex.Sessions(sessions).TranscodeLoad())
+}
+
 func TestSession_GetTitle(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -187,3 +242,236 @@ func TestSession_GetMediaMode(t *testing.T) {
 		})
 	}
 }
+
+func TestSession_HardwareTranscode(t *testing.T) {
+	tests := []struct {
+		name             string
+		transcoder       plex.SessionTranscoder
+		wantRequested    bool
+		wantFullPipeline bool
+	}{
+		{
+			name:       "software",
+			transcoder: plex.SessionTranscoder{VideoDecision: "transcode"},
+		},
+		{
+			name:          "hardware requested",
+			transcoder:    plex.SessionTranscoder{VideoDecision: "transcode", TranscodeHwRequested: true},
+			wantRequested: true,
+		},
+		{
+			name:             "hardware",
+			transcoder:       plex.SessionTranscoder{VideoDecision: "transcode", TranscodeHwRequested: true, TranscodeHwFullPipeline: true},
+			wantRequested:    true,
+			wantFullPipeline: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := plex.Session{TranscodeSession: tt.transcoder}
+			requested, fullPipeline := s.HardwareTranscode()
+			assert.Equal(t, tt.wantRequested, requested)
+			assert.Equal(t, tt.wantFullPipeline, fullPipeline)
+			assert.Equal(t, tt.wantFullPipeline, s.IsHardwareTranscoding())
+		})
+	}
+}
+
+func TestDecision_UnmarshalJSON(t *testing.T) {
+	const input = `{
+		"Media": [
+			{ "Part": [ { "decision": "directplay" } ] },
+			{ "Part": [ { "decision": "transcode", "Stream": [ { "decision": "copy" } ] } ] }
+		],
+		"TranscodeSession": { "videoDecision": "copy", "audioDecision": "transcode" }
+	}`
+	var s plex.Session
+	require.NoError(t, json.Unmarshal([]byte(input), &s))
+	assert.Equal(t, plex.DecisionDirectPlay, s.Media[0].Part[0].Decision)
+	assert.Equal(t, plex.DecisionTranscode, s.Media[1].Part[0].Decision)
+	assert.Equal(t, plex.DecisionCopy, s.Media[1].Part[0].Stream[0].Decision)
+	assert.Equal(t, plex.DecisionCopy, s.TranscodeSession.VideoDecision)
+	assert.Equal(t, plex.DecisionTranscode, s.TranscodeSession.AudioDecision)
+	assert.Equal(t, "copy,directplay", s.GetVideoMode())
+}
+
+func TestMediaSessionPartStream_Subtitles(t *testing.T) {
+	tests := []struct {
+		name         string
+		stream       plex.MediaSessionPartStream
+		wantSubtitle bool
+		wantExternal bool
+	}{
+		{
+			name:   "video",
+			stream: plex.MediaSessionPartStream{StreamType: plex.StreamTypeVideo, Location: "direct"},
+		},
+		{
+			name:         "embedded subtitle",
+			stream:       plex.MediaSessionPartStream{StreamType: plex.StreamTypeSubtitle, Location: "direct"},
+			wantSubtitle: true,
+		},
+		{
+			name:         "external subtitle",
+			stream:       plex.MediaSessionPartStream{StreamType: plex.StreamTypeSubtitle, Location: "external"},
+			wantSubtitle: true,
+			wantExternal: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantSubtitle, tt.stream.IsSubtitle())
+			assert.Equal(t, tt.wantExternal, tt.stream.IsExternal())
+		})
+	}
+}
+
+func TestSession_TranscodeContext(t *testing.T) {
+	c, s := makeClientAndServer(nil)
+	defer s.Close()
+
+	sessions, err := c.GetSessions(context.Background())
+	require.NoError(t, err)
+
+	want := []plex.TranscodeContext{"", "", plex.TranscodeContextStreaming, plex.TranscodeContextStatic}
+	require.Len(t, sessions, len(want))
+	for i := range want {
+		assert.Equal(t, want[i], sessions[i].TranscodeContext())
+	}
+}
+
+func TestSession_RatingByType(t *testing.T) {
+	const input = `{
+		"rating": 9.1,
+		"Rating": [
+			{ "image": "rottentomatoes://image.rating.ripe", "type": "critic", "value": "9.1" },
+			{ "image": "rottentomatoes://image.rating.upright", "type": "audience", "value": "9.8" }
+		]
+	}`
+	var s plex.Session
+	require.NoError(t, json.Unmarshal([]byte(input), &s))
+	assert.Equal(t, 9.1, s.Rating)
+
+	value, ok := s.RatingByType("audience")
+	assert.True(t, ok)
+	assert.Equal(t, "9.8", value)
+
+	value, ok = s.RatingByType("critic")
+	assert.True(t, ok)
+	assert.Equal(t, "9.1", value)
+
+	_, ok = s.RatingByType("imdb")
+	assert.False(t, ok)
+}
+
+func TestClient_GetSessions_Gzip(t *testing.T) {
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			http.Error(w, "gzip not requested", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		_, _ = zw.Write([]byte(`{ "MediaContainer": { "size": 1, "Metadata": [ { "sessionKey": "1", "title": "pilot" } ] } }`))
+		_ = zw.Close()
+	}))
+	defer s.Close()
+
+	sessions, err := c.GetSessions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "pilot", sessions[0].Title)
+}
+
+func TestSessionMedia_ResolutionLabel_IsHDR(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantLabel string
+		wantHDR   bool
+	}{
+		{
+			name: "4k HDR",
+			input: `{ "videoResolution": "4k", "Part": [ { "Stream": [
+				{ "streamType": 1, "codec": "hevc", "colorTrc": "smpte2084", "displayTitle": "4K HDR10 (HEVC Main 10)" },
+				{ "streamType": 2, "codec": "eac3" }
+			] } ] }`,
+			wantLabel: "2160p",
+			wantHDR:   true,
+		},
+		{
+			name: "4k Dolby Vision",
+			input: `{ "videoResolution": "4k", "Part": [ { "Stream": [
+				{ "streamType": 1, "codec": "hevc", "DOVIPresent": true }
+			] } ] }`,
+			wantLabel: "2160p",
+			wantHDR:   true,
+		},
+		{
+			name: "4k HDR, no color data",
+			input: `{ "videoResolution": "4k", "Part": [ { "Stream": [
+				{ "streamType": 1, "codec": "hevc", "displayTitle": "4K HDR10 (HEVC Main 10)" }
+			] } ] }`,
+			wantLabel: "2160p",
+			wantHDR:   true,
+		},
+		{
+			name: "1080p SDR",
+			input: `{ "videoResolution": "1080", "Part": [ { "Stream": [
+				{ "streamType": 1, "codec": "h264", "colorTrc": "bt709", "displayTitle": "1080p (H.264)" },
+				{ "streamType": 3, "codec": "srt", "displayTitle": "English (HDR subtitles)" }
+			] } ] }`,
+			wantLabel: "1080p",
+			wantHDR:   false,
+		},
+		{
+			name:      "SD",
+			input:     `{ "videoResolution": "sd" }`,
+			wantLabel: "SD",
+		},
+		{
+			name:  "unknown",
+			input: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var media plex.SessionMedia
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &media))
+			assert.Equal(t, tt.wantLabel, media.ResolutionLabel())
+			assert.Equal(t, tt.wantHDR, media.IsHDR())
+		})
+	}
+}
+
+func TestMedia_ResolutionLabel(t *testing.T) {
+	for input, want := range map[string]string{"4k": "2160p", "1080": "1080p", "720p": "720p", "sd": "SD", "": ""} {
+		assert.Equal(t, want, plex.Media{VideoResolution: input}.ResolutionLabel(), input)
+	}
+}
+
+func TestClient_TerminateSessions(t *testing.T) {
+	var attempts []string
+	var reason string
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/status/sessions/terminate" {
+			http.NotFound(w, r)
+			return
+		}
+		sessionID := r.URL.Query().Get("sessionId")
+		attempts = append(attempts, sessionID)
+		reason = r.URL.Query().Get("reason")
+		if sessionID != "abc" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	errs := c.TerminateSessions(context.Background(), "server maintenance", "xyz", "abc")
+	assert.Equal(t, []string{"xyz", "abc"}, attempts)
+	assert.Equal(t, "server maintenance", reason)
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "session xyz: terminate: 404 Not Found")
+
+	assert.Empty(t, c.TerminateSessions(context.Background(), "server maintenance", "abc"))
+}
@@ -20,3 +20,8 @@ func (t *Timestamp) UnmarshalJSON(buf []byte) error {
 func (t *Timestamp) String() string {
 	return time.Time(*t).String()
 }
+
+// MarshalJSON encodes the timestamp as seconds since the epoch, the format used by Plex, so that UnmarshalJSON can decode it.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(time.Time(t).Unix(), 10)), nil
+}
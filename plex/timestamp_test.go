@@ -1,7 +1,9 @@
 package plex
 
 import (
+	"encoding/json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
 	"time"
 )
@@ -43,3 +45,21 @@ func TestTimestamp_UnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestTimestamp_MarshalJSON(t *testing.T) {
+	type container struct {
+		UpdatedAt Timestamp `json:"updatedAt"`
+	}
+	in := container{UpdatedAt: Timestamp(time.Date(2022, time.June, 22, 11, 58, 51, 0, time.UTC))}
+
+	// values (which aren't addressable) and pointers are encoded the same way
+	for _, v := range []any{in, &in} {
+		body, err := json.Marshal(v)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"updatedAt":1655899131}`, string(body))
+
+		var out container
+		require.NoError(t, json.Unmarshal(body, &out))
+		assert.Equal(t, in, out)
+	}
+}
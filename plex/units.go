@@ -0,0 +1,30 @@
+package plex
+
+import "fmt"
+
+// FormatBitrate formats a bitrate (in kbps, as reported by Plex) in human-readable form, e.g. "4.2 Mbps".
+func FormatBitrate(kbps int) string {
+	if kbps < 1000 {
+		return fmt.Sprintf("%d kbps", kbps)
+	}
+	// pick the unit after rounding, so e.g. 999,999 kbps is "1.0 Gbps" rather than "1000.0 Mbps"
+	if mbps := float64(kbps) / 1000; mbps < 1000-0.05 {
+		return fmt.Sprintf("%.1f Mbps", mbps)
+	}
+	return fmt.Sprintf("%.1f Gbps", float64(kbps)/(1000*1000))
+}
+
+// FormatBytes formats a size (in bytes) in human-readable form, using binary prefixes, e.g. "1.3 GiB".
+func FormatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	// pick the unit after rounding, so e.g. 1 MiB - 1 is "1.0 MiB" rather than "1024.0 KiB"
+	value, exp := float64(b)/unit, 0
+	for value >= unit-0.05 && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTP"[exp])
+}
@@ -0,0 +1,52 @@
+package plex_test
+
+import (
+	"github.com/clambin/mediaclients/plex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormatBitrate(t *testing.T) {
+	tests := []struct {
+		kbps int
+		want string
+	}{
+		{kbps: 0, want: "0 kbps"},
+		{kbps: 999, want: "999 kbps"},
+		{kbps: 1000, want: "1.0 Mbps"},
+		{kbps: 4200, want: "4.2 Mbps"},
+		{kbps: 999_949, want: "999.9 Mbps"},
+		{kbps: 999_999, want: "1.0 Gbps"},
+		{kbps: 1_000_000, want: "1.0 Gbps"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, plex.FormatBitrate(tt.kbps))
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{bytes: 0, want: "0 B"},
+		{bytes: 1023, want: "1023 B"},
+		{bytes: 1024, want: "1.0 KiB"},
+		{bytes: 1536, want: "1.5 KiB"},
+		{bytes: 1024*1024 - 52, want: "1023.9 KiB"},
+		{bytes: 1024*1024 - 1, want: "1.0 MiB"},
+		{bytes: 1<<40 - 1, want: "1.0 TiB"},
+		{bytes: 1024 * 1024, want: "1.0 MiB"},
+		{bytes: 1395864371, want: "1.3 GiB"},
+		{bytes: 1 << 40, want: "1.0 TiB"},
+		{bytes: 1 << 50, want: "1.0 PiB"},
+		{bytes: 1 << 60, want: "1024.0 PiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, plex.FormatBytes(tt.bytes))
+		})
+	}
+}
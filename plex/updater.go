@@ -0,0 +1,63 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrUpdatesDisabled indicates that ApplyUpdate was called on a Client created without WithAllowUpdates.
+var ErrUpdatesDisabled = errors.New("updates disabled")
+
+// UpdateStatus contains the response of Plex's /updater/status API
+type UpdateStatus struct {
+	CanInstall  bool            `json:"canInstall"`
+	CheckedAt   Timestamp       `json:"checkedAt"`
+	DownloadURL string          `json:"downloadURL"`
+	Status      int             `json:"status"`
+	Releases    []UpdateRelease `json:"Release"`
+}
+
+// UpdateRelease is a Plex Media Server release that is available for installation
+type UpdateRelease struct {
+	Key         string `json:"key"`
+	Version     string `json:"version"`
+	Added       string `json:"added"`
+	Fixed       string `json:"fixed"`
+	DownloadURL string `json:"downloadURL"`
+	State       string `json:"state"`
+}
+
+// UpdateAvailable returns true if a newer release of the Plex Media Server is available.
+func (u UpdateStatus) UpdateAvailable() bool {
+	return len(u.Releases) > 0
+}
+
+// GetUpdateStatus calls Plex' /updater/status endpoint, which reports whether a newer release of the server is available.
+// Note that the server only reports releases it found during its last check (see UpdateStatus.CheckedAt).
+func (c *Client) GetUpdateStatus(ctx context.Context) (UpdateStatus, error) {
+	return call[UpdateStatus](ctx, c, "/updater/status")
+}
+
+// CheckForUpdates makes the Plex Media Server check for new releases. Use GetUpdateStatus to get the result.
+func (c *Client) CheckForUpdates(ctx context.Context) error {
+	if err := c.send(ctx, http.MethodPut, "/updater/check"); err != nil {
+		return fmt.Errorf("check: %w", err)
+	}
+	return nil
+}
+
+// ApplyUpdate installs the latest available release of the Plex Media Server.
+//
+// As installing an update restarts the server, the Client must be created with WithAllowUpdates.
+// Otherwise, ApplyUpdate returns ErrUpdatesDisabled.
+func (c *Client) ApplyUpdate(ctx context.Context) error {
+	if !c.allowUpdates {
+		return ErrUpdatesDisabled
+	}
+	if err := c.send(ctx, http.MethodPut, "/updater/apply"); err != nil {
+		return fmt.Errorf("apply: %w", err)
+	}
+	return nil
+}
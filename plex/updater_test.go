@@ -0,0 +1,95 @@
+package plex_test
+
+import (
+	"context"
+	"github.com/clambin/mediaclients/plex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_GetUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantAvailable bool
+		wantVersion   string
+	}{
+		{
+			name: "update available",
+			body: `{ "MediaContainer": { "size": 1, "canInstall": true, "checkedAt": 1700000000, "status": 0, "Release": [
+				{ "key": "https://plex.tv/updater/releases/1", "version": "1.41.0.1234-abcdef", "added": "new stuff", "fixed": "old stuff", "downloadURL": "https://plex.tv/downloads/1", "state": "notify" }
+			] } }`,
+			wantAvailable: true,
+			wantVersion:   "1.41.0.1234-abcdef",
+		},
+		{
+			name:          "up to date",
+			body:          `{ "MediaContainer": { "size": 0, "canInstall": false, "checkedAt": 1700000000, "status": 0 } }`,
+			wantAvailable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/updater/status" {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer s.Close()
+
+			status, err := c.GetUpdateStatus(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAvailable, status.UpdateAvailable())
+			assert.Equal(t, tt.wantAvailable, status.CanInstall)
+			assert.Equal(t, time.Unix(1700000000, 0).UTC(), time.Time(status.CheckedAt))
+			if tt.wantAvailable {
+				assert.Equal(t, tt.wantVersion, status.Releases[0].Version)
+			}
+		})
+	}
+}
+
+func TestClient_CheckForUpdates(t *testing.T) {
+	var method, path string
+	c, s := makeClientAndServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+	}))
+	defer s.Close()
+
+	require.NoError(t, c.CheckForUpdates(context.Background()))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/updater/check", path)
+}
+
+func TestClient_ApplyUpdate(t *testing.T) {
+	var method, path, query string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, query = r.Method, r.URL.Path, r.URL.RawQuery
+	}))
+	defer s.Close()
+
+	t.Run("disabled", func(t *testing.T) {
+		c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil)
+		c.HTTPClient.Transport = http.DefaultTransport
+
+		assert.ErrorIs(t, c.ApplyUpdate(context.Background()), plex.ErrUpdatesDisabled)
+		assert.Empty(t, path)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		c := plex.New("user@example.com", "somepassword", "", "", s.URL, nil, plex.WithAllowUpdates())
+		c.HTTPClient.Transport = http.DefaultTransport
+
+		require.NoError(t, c.ApplyUpdate(context.Background()))
+		assert.Equal(t, http.MethodPut, method)
+		assert.Equal(t, "/updater/apply", path)
+		assert.Empty(t, query)
+	})
+}
@@ -0,0 +1,44 @@
+package radarr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// GetMovieCount returns the number of movies in Radarr.
+//
+// Radarr's /api/v3/movie endpoint isn't paginated and there is no dedicated count endpoint, so the full movie list
+// still needs to be downloaded. However, GetMovieCount decodes the response one movie at a time, without
+// materializing the full list in memory.
+func (c *Client) GetMovieCount(ctx context.Context, reqEditors ...RequestEditorFn) (int, error) {
+	resp, err := c.GetApiV3Movie(ctx, nil, reqEditors...)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.New(resp.Status)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	t, err := dec.Token()
+	if err != nil {
+		return 0, fmt.Errorf("decode: %w", err)
+	}
+	if t != json.Delim('[') {
+		return 0, errors.New("decode: expected array")
+	}
+	var count int
+	for dec.More() {
+		var movie json.RawMessage
+		if err = dec.Decode(&movie); err != nil {
+			return count, fmt.Errorf("decode: %w", err)
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,56 @@
+package radarr_test
+
+import (
+	"context"
+	"github.com/clambin/mediaclients/radarr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_GetMovieCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    int
+		wantErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:    "movies",
+			body:    `[ { "title": "foo", "tmdbId": 1 }, { "title": "bar", "tmdbId": 2 }, { "title": "snafu", "tmdbId": 3 } ]`,
+			want:    3,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "empty",
+			body:    `[]`,
+			want:    0,
+			wantErr: assert.NoError,
+		},
+		{
+			name:    "invalid",
+			body:    `{ "title": "foo" }`,
+			wantErr: assert.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v3/movie" {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer s.Close()
+
+			c, err := radarr.NewClient(s.URL)
+			require.NoError(t, err)
+			count, err := c.GetMovieCount(context.Background())
+			tt.wantErr(t, err)
+			assert.Equal(t, tt.want, count)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package sonarr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// CompletionRatio returns the ratio of downloaded episodes to the total number of episodes, over all seasons of the series.
+// Returns a value between 0.0 and 1.0. If the series has no episodes (or no statistics), CompletionRatio returns 0.
+func (s SeriesResource) CompletionRatio() float64 {
+	if s.Seasons == nil {
+		return 0
+	}
+	var files, total int32
+	for _, season := range *s.Seasons {
+		if season.Statistics == nil {
+			continue
+		}
+		if season.Statistics.EpisodeFileCount != nil {
+			files += *season.Statistics.EpisodeFileCount
+		}
+		if season.Statistics.TotalEpisodeCount != nil {
+			total += *season.Statistics.TotalEpisodeCount
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(files) / float64(total)
+}
+
+// WalkSeries calls fn for each series in Sonarr. If fn returns an error, WalkSeries stops and returns that error.
+//
+// Sonarr's /api/v3/series endpoint isn't paginated, so the full series list is always downloaded. However,
+// unlike GetApiV3SeriesWithResponse, WalkSeries decodes the response one series at a time, without materializing
+// the full list in memory.
+func (c *Client) WalkSeries(ctx context.Context, params *GetApiV3SeriesParams, fn func(SeriesResource) error, reqEditors ...RequestEditorFn) error {
+	resp, err := c.GetApiV3Series(ctx, params, reqEditors...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	t, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	if t != json.Delim('[') {
+		return errors.New("decode: expected array")
+	}
+	for dec.More() {
+		var series SeriesResource
+		if err = dec.Decode(&series); err != nil {
+			return fmt.Errorf("decode: %w", err)
+		}
+		if err = fn(series); err != nil {
+			return err
+		}
+	}
+	return nil
+}
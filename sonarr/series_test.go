@@ -0,0 +1,124 @@
+package sonarr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/clambin/mediaclients/sonarr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeriesResource_CompletionRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  float64
+	}{
+		{
+			name: "two seasons",
+			input: `{ "title": "foo", "seasons": [
+				{ "seasonNumber": 1, "monitored": true, "statistics": { "episodeFileCount": 10, "totalEpisodeCount": 10 } },
+				{ "seasonNumber": 2, "monitored": true, "statistics": { "episodeFileCount": 2, "totalEpisodeCount": 6 } }
+			]}`,
+			want: 0.75,
+		},
+		{
+			name:  "no statistics",
+			input: `{ "title": "foo", "seasons": [ { "seasonNumber": 1, "monitored": false } ] }`,
+			want:  0,
+		},
+		{
+			name:  "no seasons",
+			input: `{ "title": "foo" }`,
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var series sonarr.SeriesResource
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &series))
+			assert.Equal(t, tt.want, series.CompletionRatio())
+		})
+	}
+}
+
+func TestClient_WalkSeries(t *testing.T) {
+	const count = 5000
+	var body strings.Builder
+	body.WriteString("[")
+	for i := range count {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		body.WriteString(`{ "id": ` + strconv.Itoa(i+1) + `, "title": "series ` + strconv.Itoa(i+1) + `", "seasons": [ { "seasonNumber": 1, "monitored": true } ] }`)
+	}
+	body.WriteString("]")
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/series" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	defer s.Close()
+
+	c, err := sonarr.NewClient(s.URL)
+	require.NoError(t, err)
+
+	var seen int
+	err = c.WalkSeries(context.Background(), nil, func(series sonarr.SeriesResource) error {
+		seen++
+		assert.Equal(t, int32(seen), *series.Id)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, count, seen)
+
+	// fn can stop the walk
+	errStop := errors.New("stop")
+	seen = 0
+	err = c.WalkSeries(context.Background(), nil, func(series sonarr.SeriesResource) error {
+		if seen++; seen == 10 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 10, seen)
+}
+
+func TestClient_WalkSeries_Deadline(t *testing.T) {
+	// the server sends the first series, then stalls
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[ { "id": 1, "title": "foo" },`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer s.Close()
+
+	c, err := sonarr.NewClient(s.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var seen int
+	start := time.Now()
+	err = c.WalkSeries(ctx, nil, func(sonarr.SeriesResource) error {
+		seen++
+		return nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, seen)
+	assert.Less(t, time.Since(start), time.Second)
+}